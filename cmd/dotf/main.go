@@ -13,11 +13,33 @@ import (
 func main() {
 	opProvider := &sysop.Provider{}
 
+	waitFlag := &cli.DurationFlag{
+		Name:  "wait",
+		Usage: "wait this long for another running dotf to finish, e.g. 1m",
+	}
+
+	jobsFlag := &cli.IntFlag{
+		Name:  "jobs",
+		Value: commands.DefaultJobs,
+		Usage: "how many tracked files are validated and copied at the same time",
+	}
+
 	app := &cli.App{
 		Name:      "dotf",
 		Usage:     "a simple dotfile manager",
-		UsageText: "dotf command <command arguments>",
-		HideHelp:  true,
+		UsageText: "dotf [--output format] command <command arguments>",
+		Description: "list, status, log and snapshot list print their result to stdout, with --output json as\n   " +
+			"a single JSON document whose fields are described in docs/output.md. Prompts and warnings\n   " +
+			"are printed to stderr.",
+		HideHelp: true,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Value:   commands.OutputTable,
+				Usage:   "output format of list, status, log and snapshot list: table, plain or json",
+			},
+		},
 		Commands: []*cli.Command{
 			{
 				Name:      "init",
@@ -25,13 +47,14 @@ func main() {
 				Usage:     "initialize dotf",
 				ArgsUsage: "<path to dotfile repo>",
 				HideHelp:  true,
-				Action: func(c *cli.Context) error {
+				Flags:     []cli.Flag{waitFlag},
+				Action: locked(opProvider, func(c *cli.Context) error {
 					if c.Args().Len() != 1 {
 						return cli.ShowCommandHelp(c, "init")
 					}
 
 					return commands.Init(opProvider, c.Args().First())
-				},
+				}),
 			},
 			{
 				Name:      "add",
@@ -39,13 +62,14 @@ func main() {
 				Usage:     "track a new file",
 				ArgsUsage: "<path to file> <path in repo>",
 				HideHelp:  true,
-				Action: func(c *cli.Context) error {
+				Flags:     []cli.Flag{waitFlag},
+				Action: locked(opProvider, func(c *cli.Context) error {
 					if c.Args().Len() != 2 {
 						return cli.ShowCommandHelp(c, "add")
 					}
 
 					return commands.Add(opProvider, c.Args().First(), c.Args().Get(1))
-				},
+				}),
 			},
 			{
 				Name:      "rm",
@@ -53,34 +77,258 @@ func main() {
 				Usage:     "remove tracking of file",
 				ArgsUsage: "<path to file>",
 				HideHelp:  true,
-				Action: func(c *cli.Context) error {
+				Flags:     []cli.Flag{waitFlag},
+				Action: locked(opProvider, func(c *cli.Context) error {
 					if c.Args().Len() != 1 {
 						return cli.ShowCommandHelp(c, "rm")
 					}
 
 					return commands.Remove(opProvider, c.Args().First())
-				},
+				}),
 			},
 			{
 				Name:      "pull",
 				Usage:     "pull the repository and replace all dotfiles",
 				ArgsUsage: " ",
 				HideHelp:  true,
-				Action: func(c *cli.Context) error {
-					return commands.Pull(opProvider)
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "strategy",
+						Usage: "how to handle diverged histories: ff-only, merge, rebase or reset",
+					},
+					&cli.StringFlag{
+						Name:  "dirty",
+						Value: commands.DirtyAbort,
+						Usage: "how to handle uncommitted changes in the repo: abort, commit or stash",
+					},
+					&cli.StringFlag{
+						Name:  "rev",
+						Usage: "deploy the dotfiles of a commit, tag or branch without updating the repo",
+					},
+					jobsFlag,
+					waitFlag,
 				},
+				Action: locked(opProvider, func(c *cli.Context) error {
+					return commands.Pull(opProvider, commands.PullOptions{
+						Strategy: c.String("strategy"),
+						Dirty:    c.String("dirty"),
+						Rev:      c.String("rev"),
+						Jobs:     c.Int("jobs"),
+					})
+				}),
 			},
 			{
 				Name:      "push",
 				Usage:     "copy all dotfiles to the repository and push it to the remote",
-				ArgsUsage: "<commit message>",
+				ArgsUsage: "[commit message]",
+				HideHelp:  true,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "no-push",
+						Aliases: []string{"offline"},
+						Usage:   "only commit, push with the next push",
+					},
+					&cli.BoolFlag{
+						Name:  "confirm",
+						Usage: "show the changes and ask before committing them",
+					},
+					jobsFlag,
+					waitFlag,
+				},
+				Action: locked(opProvider, func(c *cli.Context) error {
+					return commands.Push(opProvider, commands.PushOptions{
+						Message: strings.Join(c.Args().Slice(), " "),
+						NoPush:  c.Bool("no-push"),
+						Confirm: c.Bool("confirm"),
+						Jobs:    c.Int("jobs"),
+					})
+				}),
+			},
+			{
+				Name:      "sync",
+				Usage:     "commit local changes, combine them with the remote and deploy the result",
+				ArgsUsage: "[commit message]",
+				HideHelp:  true,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "strategy",
+						Usage: "how to combine diverged histories: ff-only, merge or rebase",
+					},
+					jobsFlag,
+					waitFlag,
+				},
+				Action: locked(opProvider, func(c *cli.Context) error {
+					return commands.Sync(opProvider, commands.SyncOptions{
+						Message:  strings.Join(c.Args().Slice(), " "),
+						Strategy: c.String("strategy"),
+						Jobs:     c.Int("jobs"),
+					})
+				}),
+			},
+			{
+				Name:      "watch",
+				Usage:     "commit tracked files whenever they change",
+				ArgsUsage: " ",
+				HideHelp:  true,
+				Flags: []cli.Flag{
+					&cli.DurationFlag{
+						Name:  "debounce",
+						Value: commands.DefaultWatchDebounce,
+						Usage: "how long to wait for further changes before committing",
+					},
+					&cli.DurationFlag{
+						Name:  "push-every",
+						Usage: "push the commits in this interval, e.g. 10m (default: only commit)",
+					},
+					waitFlag,
+				},
+				Action: func(c *cli.Context) error {
+					return commands.Watch(opProvider, commands.WatchOptions{
+						Debounce:     c.Duration("debounce"),
+						PushInterval: c.Duration("push-every"),
+						Wait:         c.Duration("wait"),
+					})
+				},
+			},
+			{
+				Name:      "status",
+				Aliases:   []string{"s"},
+				Usage:     "show changed tracked files and unpushed commits",
+				ArgsUsage: " ",
+				HideHelp:  true,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "rev",
+						Usage: "compare against a commit, tag or branch instead of the repo checkout",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					return commands.Status(opProvider, commands.StatusOptions{Rev: c.String("rev"), Output: c.String("output")})
+				},
+			},
+			{
+				Name:      "log",
+				Usage:     "show the commits which changed a tracked file",
+				ArgsUsage: "<path to file>",
 				HideHelp:  true,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "verify",
+						Usage: "show whether the commits are signed by a trusted key",
+					},
+				},
 				Action: func(c *cli.Context) error {
-					if c.Args().Len() == 0 {
-						return cli.ShowCommandHelp(c, "push")
+					if c.Args().Len() != 1 {
+						return cli.ShowCommandHelp(c, "log")
+					}
+
+					return commands.Log(opProvider, c.Args().First(), commands.LogOptions{Verify: c.Bool("verify"), Output: c.String("output")})
+				},
+			},
+			{
+				Name:      "rollback",
+				Usage:     "restore a tracked file as it was in a commit, tag or branch",
+				ArgsUsage: "<path to file> <revision>",
+				HideHelp:  true,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "commit",
+						Usage: "commit the restored file, it is pushed with the next push",
+					},
+					waitFlag,
+				},
+				Action: locked(opProvider, func(c *cli.Context) error {
+					if c.Args().Len() != 2 {
+						return cli.ShowCommandHelp(c, "rollback")
 					}
 
-					return commands.Push(opProvider, strings.Join(c.Args().Slice(), " "))
+					return commands.Rollback(
+						opProvider,
+						c.Args().First(),
+						c.Args().Get(1),
+						commands.RollbackOptions{Commit: c.Bool("commit")},
+					)
+				}),
+			},
+			{
+				Name:      "snapshot",
+				Usage:     "push all dotfiles and tag the result",
+				ArgsUsage: "<snapshot name>",
+				HideHelp:  true,
+				Flags:     []cli.Flag{waitFlag},
+				Subcommands: []*cli.Command{
+					{
+						Name:      "list",
+						Usage:     "show all snapshots",
+						ArgsUsage: " ",
+						HideHelp:  true,
+						Flags:     []cli.Flag{waitFlag},
+						Action: locked(opProvider, func(c *cli.Context) error {
+							return commands.ListSnapshots(opProvider, commands.ListSnapshotsOptions{Output: c.String("output")})
+						}),
+					},
+					{
+						Name:      "restore",
+						Usage:     "replace all dotfiles with the ones of a snapshot",
+						ArgsUsage: "<snapshot name>",
+						HideHelp:  true,
+						Flags:     []cli.Flag{waitFlag},
+						Action: locked(opProvider, func(c *cli.Context) error {
+							if c.Args().Len() != 1 {
+								return cli.ShowSubcommandHelp(c)
+							}
+
+							return commands.RestoreSnapshot(opProvider, c.Args().First())
+						}),
+					},
+				},
+				Action: locked(opProvider, func(c *cli.Context) error {
+					if c.Args().Len() != 1 {
+						return cli.ShowSubcommandHelp(c)
+					}
+
+					return commands.Snapshot(opProvider, c.Args().First())
+				}),
+			},
+			{
+				Name:     "schedule",
+				Usage:    "run sync periodically in the background",
+				HideHelp: true,
+				Subcommands: []*cli.Command{
+					{
+						Name:      "install",
+						Usage:     "install a systemd user timer, or a crontab entry without systemd",
+						ArgsUsage: " ",
+						HideHelp:  true,
+						Flags: []cli.Flag{
+							&cli.DurationFlag{
+								Name:     "every",
+								Required: true,
+								Usage:    "how often to sync, e.g. 30m or 1h",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							return commands.InstallSchedule(opProvider, commands.ScheduleOptions{Every: c.Duration("every")})
+						},
+					},
+					{
+						Name:      "status",
+						Usage:     "show how sync is scheduled",
+						ArgsUsage: " ",
+						HideHelp:  true,
+						Action: func(c *cli.Context) error {
+							return commands.ScheduleStatus(opProvider)
+						},
+					},
+					{
+						Name:      "remove",
+						Usage:     "stop running sync periodically",
+						ArgsUsage: " ",
+						HideHelp:  true,
+						Action: func(c *cli.Context) error {
+							return commands.RemoveSchedule(opProvider)
+						},
+					},
 				},
 			},
 			{
@@ -90,7 +338,7 @@ func main() {
 				ArgsUsage: " ",
 				HideHelp:  true,
 				Action: func(c *cli.Context) error {
-					return commands.List(opProvider)
+					return commands.List(opProvider, commands.ListOptions{Output: c.String("output")})
 				},
 			},
 		},
@@ -98,7 +346,21 @@ func main() {
 
 	err := app.Run(os.Args)
 	if err != nil {
-		fmt.Printf("%v\n", err)
+		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(-1)
 	}
 }
+
+// locked runs action while holding the dotf lock, so commands which change the
+// repo or the tracked files never run at the same time.
+func locked(sys *sysop.Provider, action cli.ActionFunc) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		release, err := commands.Lock(sys, commands.LockOptions{Wait: c.Duration("wait")})
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		return action(c)
+	}
+}
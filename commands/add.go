@@ -24,17 +24,14 @@ func trackNewFile(sys dotf.SysOpsProvider, dotfilePath, systemFilePath, repoFile
 		return fmt.Errorf("add: could not build absolute path: %v", err)
 	}
 
-	cfg, err := readConfig(sys, dotfilePath)
-
-	if err != nil {
-		return fmt.Errorf("add: %v", err)
-	}
-
-	trackedFiles := cfg.TrackedFiles
-	trackedFiles = append(trackedFiles, dotf.TrackedFile{repoFilePath, absoluteSystemFilePath})
-	cfg.TrackedFiles = trackedFiles
-
-	err = writeConfig(sys, dotfilePath, cfg)
+	err = updateConfig(sys, dotfilePath, func(cfg *dotf.Config) error {
+		cfg.TrackedFiles = append(
+			cfg.TrackedFiles,
+			dotf.TrackedFile{PathInRepo: repoFilePath, PathOnSystem: absoluteSystemFilePath},
+		)
+
+		return nil
+	})
 
 	if err != nil {
 		return fmt.Errorf("add: %v", err)
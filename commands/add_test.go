@@ -116,7 +116,7 @@ func TestAdd_ShouldFailIfConfigCannotBeSerialized(t *testing.T) {
 	m.EXPECT().ExpandPath("/home//.vimrc").Return("/home/.vimrc", nil)
 	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
 	m.EXPECT().DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).Return(nil)
-	m.EXPECT().SerializeConfig(gomock.Eq(dotf.Config{"", false, []dotf.TrackedFile{{".vimrc", "/home/.vimrc"}}})).Return(nil, errors.New("error"))
+	m.EXPECT().SerializeConfig(gomock.Eq(dotf.Config{Repo: "", CreateBackups: false, TrackedFiles: []dotf.TrackedFile{{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"}}})).Return(nil, errors.New("error"))
 
 	err := commands.Add(m, "/home//.vimrc", ".vimrc")
 
@@ -138,8 +138,8 @@ func TestAdd_ShouldFailIfConfigCannotBeWritten(t *testing.T) {
 	m.EXPECT().ExpandPath("/home//.vimrc").Return("/home/.vimrc", nil)
 	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
 	m.EXPECT().DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).Return(nil)
-	m.EXPECT().SerializeConfig(gomock.Eq(dotf.Config{"", false, []dotf.TrackedFile{{".vimrc", "/home/.vimrc"}}})).Return([]byte("ABC"), nil)
-	m.EXPECT().WriteFile("/home/.dotf", []byte("ABC")).Return(errors.New("error"))
+	m.EXPECT().SerializeConfig(gomock.Eq(dotf.Config{Repo: "", CreateBackups: false, TrackedFiles: []dotf.TrackedFile{{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"}}})).Return([]byte("ABC"), nil)
+	m.EXPECT().ReplaceFile("/home/.dotf", []byte("ABC"), []byte("ABC")).Return(errors.New("error"))
 
 	err := commands.Add(m, "/home//.vimrc", ".vimrc")
 
@@ -161,8 +161,8 @@ func TestAdd_ShouldWorkSuccessfullyIfNoErrorsOccur(t *testing.T) {
 	m.EXPECT().ExpandPath("/home//.vimrc").Return("/home/.vimrc", nil)
 	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
 	m.EXPECT().DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).Return(nil)
-	m.EXPECT().SerializeConfig(gomock.Eq(dotf.Config{"", false, []dotf.TrackedFile{{".vimrc", "/home/.vimrc"}}})).Return([]byte("ABC"), nil)
-	m.EXPECT().WriteFile("/home/.dotf", []byte("ABC")).Return(nil)
+	m.EXPECT().SerializeConfig(gomock.Eq(dotf.Config{Repo: "", CreateBackups: false, TrackedFiles: []dotf.TrackedFile{{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"}}})).Return([]byte("ABC"), nil)
+	m.EXPECT().ReplaceFile("/home/.dotf", []byte("ABC"), []byte("ABC")).Return(nil)
 
 	err := commands.Add(m, "/home//.vimrc", ".vimrc")
 
@@ -170,3 +170,62 @@ func TestAdd_ShouldWorkSuccessfullyIfNoErrorsOccur(t *testing.T) {
 		t.Fatalf("Expected err to be nil")
 	}
 }
+
+func TestAdd_ShouldReapplyChangeIfConfigWasChangedConcurrently(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	zshrc := dotf.TrackedFile{PathInRepo: ".zshrc", PathOnSystem: "/home/.zshrc"}
+	vimrc := dotf.TrackedFile{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ExpandPath("/home//.vimrc").Return("/home/.vimrc", nil)
+
+	gomock.InOrder(
+		m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil),
+		m.EXPECT().DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).Return(nil),
+		m.EXPECT().SerializeConfig(gomock.Eq(dotf.Config{TrackedFiles: []dotf.TrackedFile{vimrc}})).Return([]byte("DEF"), nil),
+		m.EXPECT().ReplaceFile("/home/.dotf", []byte("ABC"), []byte("DEF")).Return(dotf.ErrConflict),
+		m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("GHI"), nil),
+		m.EXPECT().
+			DeserializeConfig(gomock.Eq([]byte("GHI")), gomock.AssignableToTypeOf(&dotf.Config{})).
+			SetArg(1, dotf.Config{TrackedFiles: []dotf.TrackedFile{zshrc}}).
+			Return(nil),
+		m.EXPECT().SerializeConfig(gomock.Eq(dotf.Config{TrackedFiles: []dotf.TrackedFile{zshrc, vimrc}})).Return([]byte("JKL"), nil),
+		m.EXPECT().ReplaceFile("/home/.dotf", []byte("GHI"), []byte("JKL")).Return(nil),
+	)
+
+	err := commands.Add(m, "/home//.vimrc", ".vimrc")
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestAdd_ShouldFailIfConfigKeepsChangingConcurrently(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ExpandPath("/home//.vimrc").Return("/home/.vimrc", nil)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil).Times(5)
+	m.EXPECT().DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).Return(nil).Times(5)
+	m.EXPECT().SerializeConfig(gomock.Any()).Return([]byte("DEF"), nil).Times(5)
+	m.EXPECT().ReplaceFile("/home/.dotf", []byte("ABC"), []byte("DEF")).Return(dotf.ErrConflict).Times(5)
+
+	err := commands.Add(m, "/home//.vimrc", ".vimrc")
+
+	if err == nil {
+		t.Fatalf("Expected err not to be nil")
+	}
+}
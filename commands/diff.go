@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// diffLine is a single line of a line based diff. Op is ' ' for unchanged,
+// '-' for removed and '+' for added lines.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+func diffLines(from, to string) []diffLine {
+	var lines []diffLine
+
+	for _, d := range diff.Do(from, to) {
+		op := byte(' ')
+
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			op = '-'
+		case diffmatchpatch.DiffInsert:
+			op = '+'
+		}
+
+		for _, text := range strings.SplitAfter(d.Text, "\n") {
+			if text != "" {
+				lines = append(lines, diffLine{op: op, text: text})
+			}
+		}
+	}
+
+	return lines
+}
+
+// diffStat counts the added and removed lines between two contents.
+func diffStat(from, to []byte) (int, int) {
+	added, removed := 0, 0
+
+	for _, line := range diffLines(string(from), string(to)) {
+		switch line.op {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+
+	return added, removed
+}
+
+// unifiedDiff renders the changes from one content to another in the unified
+// diff format.
+func unifiedDiff(fromName, toName string, from, to []byte) string {
+	lines := diffLines(string(from), string(to))
+
+	stringBuilder := &strings.Builder{}
+	stringBuilder.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", fromName, toName))
+
+	// line numbers of lines[start] in from and to
+	fromLine, toLine := 1, 1
+
+	for start := 0; start < len(lines); {
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+
+		if first == len(lines) {
+			break
+		}
+
+		hunkStart := first - diffContext
+		if hunkStart < start {
+			hunkStart = start
+		}
+
+		// extend the hunk while the gap to the next change is too small to
+		// show both contexts separately
+		end := first
+		for {
+			for end < len(lines) && lines[end].op != ' ' {
+				end++
+			}
+
+			next := end
+			for next < len(lines) && lines[next].op == ' ' {
+				next++
+			}
+
+			if next == len(lines) || next-end > 2*diffContext {
+				break
+			}
+
+			end = next
+		}
+
+		hunkEnd := end + diffContext
+		if hunkEnd > len(lines) {
+			hunkEnd = len(lines)
+		}
+
+		// lines between start and hunkStart are unchanged
+		fromLine += hunkStart - start
+		toLine += hunkStart - start
+
+		fromCount, toCount := 0, 0
+		hunk := &strings.Builder{}
+
+		for _, line := range lines[hunkStart:hunkEnd] {
+			if line.op != '+' {
+				fromCount++
+			}
+
+			if line.op != '-' {
+				toCount++
+			}
+
+			hunk.WriteByte(line.op)
+			hunk.WriteString(line.text)
+
+			if !strings.HasSuffix(line.text, "\n") {
+				hunk.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		stringBuilder.WriteString(fmt.Sprintf(
+			"@@ -%d,%d +%d,%d @@\n",
+			hunkLine(fromLine, fromCount), fromCount, hunkLine(toLine, toCount), toCount,
+		))
+		stringBuilder.WriteString(hunk.String())
+
+		fromLine += fromCount
+		toLine += toCount
+		start = hunkEnd
+	}
+
+	return stringBuilder.String()
+}
+
+// hunkLine returns the start line of a hunk side. Empty sides refer to the
+// line before the hunk like diff does.
+func hunkLine(line, count int) int {
+	if count == 0 {
+		return line - 1
+	}
+
+	return line
+}
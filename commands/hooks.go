@@ -0,0 +1,165 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"bakku.dev/dotf"
+)
+
+// Names of the hooks, passed to them as DOTF_HOOK.
+const (
+	hookPrePull  = "prePull"
+	hookPostPull = "postPull"
+	hookPrePush  = "prePush"
+	hookPostPush = "postPush"
+	hookOnChange = "onChange"
+)
+
+// Operations which run hooks, passed to them as DOTF_OPERATION.
+const (
+	operationPull    = "pull"
+	operationPush    = "push"
+	operationSync    = "sync"
+	operationRestore = "restore"
+)
+
+// hookFile is a changed tracked file as it is passed to hooks.
+type hookFile struct {
+	PathInRepo   string `json:"pathInRepo"`
+	PathOnSystem string `json:"pathOnSystem"`
+}
+
+// hookContext describes the running operation to a hook. It is passed as JSON
+// document on stdin and as DOTF_* environment variables. ChangedFiles is only
+// known to post and onChange hooks.
+type hookContext struct {
+	Operation    string     `json:"operation"`
+	Hook         string     `json:"hook"`
+	Repo         string     `json:"repo"`
+	ChangedFiles []hookFile `json:"changedFiles"`
+}
+
+// runHook runs a hook command in the repo and logs its output. It returns an
+// error naming the hook and its exit code if the hook failed. onChange hooks
+// are named after the first changed file.
+func runHook(sys dotf.SysOpsProvider, cfg dotf.Config, operation, name, command string, changed []dotf.TrackedFile) error {
+	if command == "" {
+		return nil
+	}
+
+	hook := hookContext{
+		Operation:    operation,
+		Hook:         name,
+		Repo:         cfg.Repo,
+		ChangedFiles: []hookFile{},
+	}
+
+	if name == hookOnChange && len(changed) > 0 {
+		name += " of " + changed[0].PathOnSystem
+	}
+
+	var systemPaths []string
+
+	for _, tf := range changed {
+		hook.ChangedFiles = append(hook.ChangedFiles, hookFile{PathInRepo: tf.PathInRepo, PathOnSystem: tf.PathOnSystem})
+		systemPaths = append(systemPaths, tf.PathOnSystem)
+	}
+
+	stdin, err := json.Marshal(hook)
+
+	if err != nil {
+		return fmt.Errorf("could not serialize context of hook %s: %v", name, err)
+	}
+
+	sys.Log(fmt.Sprintf("running %s hook: %s\n", name, command))
+
+	output, exitCode, err := sys.RunCommand(dotf.Command{
+		Line: command,
+		Dir:  cfg.Repo,
+		Env: []string{
+			"DOTF_OPERATION=" + hook.Operation,
+			"DOTF_HOOK=" + hook.Hook,
+			"DOTF_REPO=" + hook.Repo,
+			"DOTF_CHANGED_FILES=" + strings.Join(systemPaths, "\n"),
+		},
+		Stdin: append(stdin, '\n'),
+	})
+
+	if len(output) > 0 {
+		sys.Log(string(output))
+	}
+
+	if err != nil {
+		return fmt.Errorf("hook %s could not be run: %v", name, err)
+	}
+
+	if exitCode != 0 {
+		return fmt.Errorf("hook %s failed with exit code %d", name, exitCode)
+	}
+
+	return nil
+}
+
+// runPreHook runs a hook before an operation. A failing pre hook vetoes the
+// operation.
+func runPreHook(sys dotf.SysOpsProvider, cfg dotf.Config, operation, name, command string) error {
+	err := runHook(sys, cfg, operation, name, command, nil)
+
+	if err != nil {
+		return fmt.Errorf("%v, the %s was vetoed", err, operation)
+	}
+
+	return nil
+}
+
+// runPostHook runs a hook after an operation succeeded. A failing post hook
+// is reported but cannot undo the operation.
+func runPostHook(sys dotf.SysOpsProvider, cfg dotf.Config, operation, name, command string, changed []dotf.TrackedFile) {
+	err := runHook(sys, cfg, operation, name, command, changed)
+
+	if err != nil {
+		sys.Log(err.Error() + "\n")
+	}
+}
+
+// runChangeHooks runs the onChange hooks of the given tracked files. Files
+// which share a command trigger it only once and are passed to it together.
+func runChangeHooks(sys dotf.SysOpsProvider, cfg dotf.Config, operation string, changed []dotf.TrackedFile) {
+	var lines []string
+
+	filesByCommand := make(map[string][]dotf.TrackedFile)
+
+	for _, tf := range changed {
+		if tf.OnChange == "" {
+			continue
+		}
+
+		if _, ok := filesByCommand[tf.OnChange]; !ok {
+			lines = append(lines, tf.OnChange)
+		}
+
+		filesByCommand[tf.OnChange] = append(filesByCommand[tf.OnChange], tf)
+	}
+
+	for _, line := range lines {
+		runPostHook(sys, cfg, operation, hookOnChange, line, filesByCommand[line])
+	}
+}
+
+// committedFiles returns the tracked files which were added or modified by a
+// commit.
+func committedFiles(cfg dotf.Config, changes dotf.FileChanges) []dotf.TrackedFile {
+	var files []dotf.TrackedFile
+
+	for _, file := range append(append([]string{}, changes.Added...), changes.Modified...) {
+		for _, tf := range cfg.TrackedFiles {
+			if strings.TrimPrefix(tf.PathInRepo, "/") == strings.TrimPrefix(file, "/") {
+				files = append(files, tf)
+			}
+		}
+	}
+
+	return files
+}
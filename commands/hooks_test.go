@@ -0,0 +1,214 @@
+package commands_test
+
+import (
+	"testing"
+
+	"bakku.dev/dotf"
+	"bakku.dev/dotf/commands"
+	"bakku.dev/dotf/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+func TestPull_ShouldRunHooksAndOnChangeHooksOfChangedFiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.tmux.conf", PathOnSystem: "/home/.tmux.conf", OnChange: "tmux source-file ~/.tmux.conf"},
+			{PathInRepo: "/.config/i3/config", PathOnSystem: "/home/.config/i3/config", OnChange: "i3-msg reload"},
+			{PathInRepo: "/.config/i3/status", PathOnSystem: "/home/.config/i3/status", OnChange: "i3-msg reload"},
+		},
+		Hooks: dotf.Hooks{
+			PrePull:  "./check.sh",
+			PostPull: "notify-send dotf pulled",
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+
+	gomock.InOrder(
+		m.EXPECT().Log("running prePull hook: ./check.sh\n"),
+		m.EXPECT().
+			RunCommand(dotf.Command{
+				Line: "./check.sh",
+				Dir:  "/home/repo",
+				Env: []string{
+					"DOTF_OPERATION=pull",
+					"DOTF_HOOK=prePull",
+					"DOTF_REPO=/home/repo",
+					"DOTF_CHANGED_FILES=",
+				},
+				Stdin: []byte(`{"operation":"pull","hook":"prePull","repo":"/home/repo","changedFiles":[]}` + "\n"),
+			}).
+			Return([]byte("all good\n"), 0, nil),
+		m.EXPECT().Log("all good\n"),
+		m.EXPECT().DirtyFiles("/home/repo").Return(nil, nil),
+		m.EXPECT().UpdateRepo("/home/repo", dotf.GitConfig{}).Return(nil),
+	)
+
+	expectRepoReader(ctrl, m, "HEAD", map[string]string{
+		"/.tmux.conf":        "set -g mouse on\n",
+		"/.config/i3/config": "bar {}\n",
+		"/.config/i3/status": "general {}\n",
+	})
+
+	m.EXPECT().PathExists("/home/.tmux.conf").Return(true)
+	m.EXPECT().ReadFile("/home/.tmux.conf").Return([]byte("set -g mouse on\n"), nil)
+	m.EXPECT().PathExists("/home/.config/i3/config").Return(true)
+	m.EXPECT().ReadFile("/home/.config/i3/config").Return([]byte("bar { position top }\n"), nil)
+	m.EXPECT().PathExists("/home/.config/i3/status").Return(false)
+	m.EXPECT().WriteFile("/home/.tmux.conf", []byte("set -g mouse on\n")).Return(nil)
+	m.EXPECT().WriteFile("/home/.config/i3/config", []byte("bar {}\n")).Return(nil)
+	m.EXPECT().WriteFile("/home/.config/i3/status", []byte("general {}\n")).Return(nil)
+
+	changedFiles := `"changedFiles":[` +
+		`{"pathInRepo":"/.config/i3/config","pathOnSystem":"/home/.config/i3/config"},` +
+		`{"pathInRepo":"/.config/i3/status","pathOnSystem":"/home/.config/i3/status"}]`
+
+	gomock.InOrder(
+		m.EXPECT().Log("running onChange of /home/.config/i3/config hook: i3-msg reload\n"),
+		m.EXPECT().
+			RunCommand(dotf.Command{
+				Line: "i3-msg reload",
+				Dir:  "/home/repo",
+				Env: []string{
+					"DOTF_OPERATION=pull",
+					"DOTF_HOOK=onChange",
+					"DOTF_REPO=/home/repo",
+					"DOTF_CHANGED_FILES=/home/.config/i3/config\n/home/.config/i3/status",
+				},
+				Stdin: []byte(`{"operation":"pull","hook":"onChange","repo":"/home/repo",` + changedFiles + "}\n"),
+			}).
+			Return(nil, 1, nil),
+		m.EXPECT().Log("hook onChange of /home/.config/i3/config failed with exit code 1\n"),
+	)
+
+	expectDeployedRevision(m, cfg, "abc123")
+
+	gomock.InOrder(
+		m.EXPECT().Log("running postPull hook: notify-send dotf pulled\n"),
+		m.EXPECT().
+			RunCommand(dotf.Command{
+				Line: "notify-send dotf pulled",
+				Dir:  "/home/repo",
+				Env: []string{
+					"DOTF_OPERATION=pull",
+					"DOTF_HOOK=postPull",
+					"DOTF_REPO=/home/repo",
+					"DOTF_CHANGED_FILES=/home/.config/i3/config\n/home/.config/i3/status",
+				},
+				Stdin: []byte(`{"operation":"pull","hook":"postPull","repo":"/home/repo",` + changedFiles + "}\n"),
+			}).
+			Return(nil, 0, nil),
+	)
+
+	err := commands.Pull(m, commands.PullOptions{})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestPush_ShouldBeVetoedByFailingPrePushHook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo:         "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{},
+		Hooks: dotf.Hooks{
+			PrePush:  "make lint",
+			PostPush: "notify-send dotf pushed",
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().Log("running prePush hook: make lint\n")
+	m.EXPECT().RunCommand(gomock.Any()).Return([]byte("lint: 1 error\n"), 2, nil)
+	m.EXPECT().Log("lint: 1 error\n")
+
+	err := commands.Push(m, commands.PushOptions{})
+
+	expected := "push: hook prePush failed with exit code 2, the push was vetoed"
+
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected err to be %q, got %v", expected, err)
+	}
+}
+
+func TestPush_ShouldPassCommittedFilesToPostPushHook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+		Hooks: dotf.Hooks{
+			PostPush: "notify-send dotf pushed",
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/").Times(2)
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(0, nil)
+	m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc")
+	m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(nil)
+	m.EXPECT().RepoChanges("/home/repo", []string{"/.vimrc"}).Return(dotf.FileChanges{Modified: []string{".vimrc"}}, nil)
+	expectSecretScan(ctrl, m)
+	m.EXPECT().CommitRepo("/home/repo", "Update .vimrc", []string{"/.vimrc"}, dotf.GitConfig{}).Return(nil)
+	m.EXPECT().PushRepo("/home/repo", dotf.GitConfig{}).Return(nil)
+	m.EXPECT().Log("running postPush hook: notify-send dotf pushed\n")
+	m.EXPECT().
+		RunCommand(dotf.Command{
+			Line: "notify-send dotf pushed",
+			Dir:  "/home/repo",
+			Env: []string{
+				"DOTF_OPERATION=push",
+				"DOTF_HOOK=postPush",
+				"DOTF_REPO=/home/repo",
+				"DOTF_CHANGED_FILES=/home/.vimrc",
+			},
+			Stdin: []byte(`{"operation":"push","hook":"postPush","repo":"/home/repo",` +
+				`"changedFiles":[{"pathInRepo":"/.vimrc","pathOnSystem":"/home/.vimrc"}]}` + "\n"),
+		}).
+		Return(nil, 0, nil)
+
+	err := commands.Push(m, commands.PushOptions{Message: "Update .vimrc"})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
@@ -54,7 +54,7 @@ func createDotfile(sys dotf.SysOpsProvider, dotfilePath string, repoPath string)
 		createBackups = true
 	}
 
-	conf := dotf.Config{repoPath, createBackups, []dotf.TrackedFile{}}
+	conf := dotf.Config{Repo: repoPath, CreateBackups: createBackups, TrackedFiles: []dotf.TrackedFile{}}
 	bytes, err := sys.SerializeConfig(conf)
 
 	if err != nil {
@@ -103,7 +103,7 @@ func TestInit_ShouldFailIfConfigCannotBeSerialized(t *testing.T) {
 	m.EXPECT().Log("Do you want to create backups of your dotfiles when pulling? (y/n): ")
 	m.EXPECT().ReadLine().Return("y", nil)
 	m.EXPECT().
-		SerializeConfig(gomock.Eq(dotf.Config{"/home/repo", true, []dotf.TrackedFile{}})).
+		SerializeConfig(gomock.Eq(dotf.Config{Repo: "/home/repo", CreateBackups: true, TrackedFiles: []dotf.TrackedFile{}})).
 		Return([]byte{}, errors.New("error"))
 
 	err := commands.Init(m, "/home/repo")
@@ -128,7 +128,7 @@ func TestInit_ShouldFailIfConfigCannotBeWrittenToFile(t *testing.T) {
 	m.EXPECT().Log("Do you want to create backups of your dotfiles when pulling? (y/n): ")
 	m.EXPECT().ReadLine().Return("n", nil)
 	m.EXPECT().
-		SerializeConfig(gomock.Eq(dotf.Config{"/home/repo", false, []dotf.TrackedFile{}})).
+		SerializeConfig(gomock.Eq(dotf.Config{Repo: "/home/repo", CreateBackups: false, TrackedFiles: []dotf.TrackedFile{}})).
 		Return([]byte("ABC"), nil)
 	m.EXPECT().WriteFile("/home/.dotf", []byte("ABC")).Return(errors.New("error"))
 
@@ -154,7 +154,7 @@ func TestInit_ShouldTerminateSuccessfullyIfNoErrorIsRaised(t *testing.T) {
 	m.EXPECT().Log("Do you want to create backups of your dotfiles when pulling? (y/n): ")
 	m.EXPECT().ReadLine().Return("y", nil)
 	m.EXPECT().
-		SerializeConfig(gomock.Eq(dotf.Config{"/home/repo", true, []dotf.TrackedFile{}})).
+		SerializeConfig(gomock.Eq(dotf.Config{Repo: "/home/repo", CreateBackups: true, TrackedFiles: []dotf.TrackedFile{}})).
 		Return([]byte("ABC"), nil)
 	m.EXPECT().WriteFile("/home/.dotf", []byte("ABC")).Return(nil)
 	m.EXPECT().Log("Successfully created file at /home/.dotf\n")
@@ -2,42 +2,68 @@ package commands
 
 import (
 	"fmt"
-	"strings"
 
 	"bakku.dev/dotf"
-	"github.com/olekukonko/tablewriter"
 )
 
+// ListOptions configures the list command.
+type ListOptions struct {
+	// Output is the output format, OutputTable if it is empty.
+	Output string
+}
+
+// ListReport is the JSON output of the list command.
+type ListReport struct {
+	TrackedFiles []TrackedFileReport `json:"trackedFiles"`
+}
+
 // List shows all currently tracked files
-func List(sys dotf.SysOpsProvider) error {
+func List(sys dotf.SysOpsProvider, opts ListOptions) error {
+	err := checkOutput(opts.Output)
+
+	if err != nil {
+		return fmt.Errorf("list: %v", err)
+	}
+
 	dotfilePath, err := getDotfConfigPath(sys)
 
 	if err != nil {
 		return fmt.Errorf("list: %v", err)
 	}
 
-	return listAllTrackedFiles(sys, dotfilePath)
+	return listAllTrackedFiles(sys, dotfilePath, opts)
 }
 
-func listAllTrackedFiles(sys dotf.SysOpsProvider, dotfilePath string) error {
+func listAllTrackedFiles(sys dotf.SysOpsProvider, dotfilePath string, opts ListOptions) error {
 	cfg, err := readConfig(sys, dotfilePath)
 
 	if err != nil {
 		return fmt.Errorf("list: %v", err)
 	}
 
-	stringBuilder := &strings.Builder{}
+	if opts.Output == OutputJSON {
+		report := ListReport{TrackedFiles: []TrackedFileReport{}}
 
-	table := tablewriter.NewWriter(stringBuilder)
-	table.SetHeader([]string{"File", "Path in repo"})
+		for _, tf := range cfg.TrackedFiles {
+			report.TrackedFiles = append(report.TrackedFiles, TrackedFileReport{PathOnSystem: tf.PathOnSystem, PathInRepo: tf.PathInRepo})
+		}
 
-	for _, tf := range cfg.TrackedFiles {
-		table.Append([]string{tf.PathOnSystem, tf.PathInRepo})
+		err = logJSON(sys, report)
+
+		if err != nil {
+			return fmt.Errorf("list: %v", err)
+		}
+
+		return nil
 	}
 
-	table.Render()
+	var rows [][]string
+
+	for _, tf := range cfg.TrackedFiles {
+		rows = append(rows, []string{tf.PathOnSystem, tf.PathInRepo})
+	}
 
-	sys.Log(stringBuilder.String())
+	sys.Log(renderRows(opts.Output, []string{"File", "Path in repo"}, rows, true))
 
 	return nil
 }
@@ -18,7 +18,7 @@ func TestList_ShouldFailIfNoHomeVarExists(t *testing.T) {
 
 	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("")
 
-	err := commands.List(m)
+	err := commands.List(m, commands.ListOptions{})
 
 	if err == nil {
 		t.Fatalf("Expected err to not be nil")
@@ -36,7 +36,7 @@ func TestList_ShouldAbortIfDotfileDoesNotExist(t *testing.T) {
 	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
 	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(false)
 
-	err := commands.List(m)
+	err := commands.List(m, commands.ListOptions{})
 
 	if err == nil {
 		t.Fatalf("Expected err not to be nil")
@@ -55,7 +55,7 @@ func TestList_ShouldFailIfConfigCannotBeRead(t *testing.T) {
 	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
 	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return(nil, errors.New("error"))
 
-	err := commands.List(m)
+	err := commands.List(m, commands.ListOptions{})
 
 	if err == nil {
 		t.Fatalf("Expected err not to be nil")
@@ -75,7 +75,7 @@ func TestList_ShouldFailIfConfigCannotBeDeserialized(t *testing.T) {
 	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
 	m.EXPECT().DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).Return(errors.New("error"))
 
-	err := commands.List(m)
+	err := commands.List(m, commands.ListOptions{})
 
 	if err == nil {
 		t.Fatalf("Expected err not to be nil")
@@ -87,11 +87,11 @@ func TestList_ShouldCorrectlyListAllTrackedFiles(t *testing.T) {
 	defer ctrl.Finish()
 
 	cfg := dotf.Config{
-		"",
-		false,
-		[]dotf.TrackedFile{
-			{".vimrc", "/home/.vimrc"},
-			{".emacs.d/init.el", "/home/.emacs.d/init.el"},
+		Repo:          "",
+		CreateBackups: false,
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"},
+			{PathInRepo: ".emacs.d/init.el", PathOnSystem: "/home/.emacs.d/init.el"},
 		},
 	}
 
@@ -116,9 +116,83 @@ func TestList_ShouldCorrectlyListAllTrackedFiles(t *testing.T) {
 		Return(nil)
 	m.EXPECT().Log(expectedTableString)
 
-	err := commands.List(m)
+	err := commands.List(m, commands.ListOptions{})
 
 	if err != nil {
 		t.Fatalf("Expected err to be nil")
 	}
 }
+
+func TestList_ShouldRejectUnknownOutputFormat(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	err := commands.List(m, commands.ListOptions{Output: "yaml"})
+
+	if err == nil || err.Error() != "list: unknown output format yaml, use table, plain or json" {
+		t.Fatalf("Expected err to name the unknown format, got %v", err)
+	}
+}
+
+func TestList_ShouldShowTrackedFilesInOutputFormat(t *testing.T) {
+	cfg := dotf.Config{
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"},
+			{PathInRepo: ".emacs.d/init.el", PathOnSystem: "/home/.emacs.d/init.el"},
+		},
+	}
+
+	tests := []struct {
+		output   string
+		expected string
+	}{
+		{
+			output: commands.OutputPlain,
+			expected: "/home/.vimrc\t.vimrc\n" +
+				"/home/.emacs.d/init.el\t.emacs.d/init.el\n",
+		},
+		{
+			output: commands.OutputJSON,
+			expected: "{\n" +
+				"  \"trackedFiles\": [\n" +
+				"    {\n" +
+				"      \"pathOnSystem\": \"/home/.vimrc\",\n" +
+				"      \"pathInRepo\": \".vimrc\"\n" +
+				"    },\n" +
+				"    {\n" +
+				"      \"pathOnSystem\": \"/home/.emacs.d/init.el\",\n" +
+				"      \"pathInRepo\": \".emacs.d/init.el\"\n" +
+				"    }\n" +
+				"  ]\n" +
+				"}\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.output, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mocks.NewMockSysOpsProvider(ctrl)
+
+			m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+			m.EXPECT().GetPathSep().Return("/")
+			m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+			m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+			m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+			m.EXPECT().
+				DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+				SetArg(1, cfg).
+				Return(nil)
+			m.EXPECT().Log(test.expected)
+
+			err := commands.List(m, commands.ListOptions{Output: test.output})
+
+			if err != nil {
+				t.Fatalf("Expected err to be nil, got %v", err)
+			}
+		})
+	}
+}
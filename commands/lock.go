@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"bakku.dev/dotf"
+)
+
+const lockFileName = ".dotf.lock"
+
+// lockPollInterval is how often a waiting dotf checks whether the lock was
+// released.
+const lockPollInterval = 500 * time.Millisecond
+
+// LockOptions configures how the dotf lock is taken.
+type LockOptions struct {
+	// Wait is how long to wait for another dotf process to release the lock.
+	// If it is zero, a held lock is an error right away.
+	Wait time.Duration
+}
+
+// lockInfo is stored in the lock file to tell who holds the lock.
+type lockInfo struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Started time.Time `json:"started"`
+}
+
+// Lock takes the advisory lock $HOME/.dotf.lock which keeps commands changing
+// the repo or the tracked files from running at the same time. Locks of
+// processes which no longer run on this host are removed. The returned
+// function releases the lock.
+func Lock(sys dotf.SysOpsProvider, opts LockOptions) (func(), error) {
+	home := sys.GetEnvVar("HOME")
+
+	if home == "" {
+		return nil, fmt.Errorf("lock: HOME env var is not set")
+	}
+
+	lockPath := sys.CleanPath(home + sys.GetPathSep() + lockFileName)
+
+	release, err := acquireLock(sys, lockPath, opts.Wait)
+
+	if err != nil {
+		return nil, fmt.Errorf("lock: %v", err)
+	}
+
+	return release, nil
+}
+
+func acquireLock(sys dotf.SysOpsProvider, lockPath string, wait time.Duration) (func(), error) {
+	host, err := sys.GetHostname()
+
+	if err != nil {
+		return nil, fmt.Errorf("could not determine hostname: %v", err)
+	}
+
+	content, err := json.Marshal(lockInfo{PID: sys.GetPid(), Host: host, Started: sys.Now()})
+
+	if err != nil {
+		return nil, fmt.Errorf("could not serialize lock: %v", err)
+	}
+
+	deadline := sys.Now().Add(wait)
+
+	for {
+		created, err := sys.CreateLockFile(lockPath, content)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if created {
+			return func() { releaseLock(sys, lockPath) }, nil
+		}
+
+		// the holder released the lock in the meantime
+		if !sys.PathExists(lockPath) {
+			continue
+		}
+
+		raw, holder, stale := inspectLock(sys, lockPath, host)
+
+		if stale {
+			// the lock is only removed if it was not taken again since it was read
+			removed, err := sys.RemoveLockFile(lockPath, raw)
+
+			if err != nil {
+				return nil, fmt.Errorf("could not remove stale lock %s: %v", lockPath, err)
+			}
+
+			if removed {
+				sys.Warn(fmt.Sprintf("removed stale lock %s of %s\n", lockPath, holder))
+			}
+
+			continue
+		}
+
+		if wait <= 0 {
+			return nil, fmt.Errorf("%s is held by %s, another dotf is running, retry with --wait to wait for it", lockPath, holder)
+		}
+
+		if !sys.Now().Before(deadline) {
+			return nil, fmt.Errorf("%s is still held by %s after waiting %s", lockPath, holder, wait)
+		}
+
+		sys.Sleep(lockPollInterval)
+	}
+}
+
+// inspectLock reads the lock, describes its holder and reports whether the
+// lock is stale because its process no longer runs on this host. Locks of
+// other hosts and unreadable locks are never stale, the holder may still run.
+func inspectLock(sys dotf.SysOpsProvider, lockPath, host string) ([]byte, string, bool) {
+	raw, err := sys.ReadFile(lockPath)
+
+	if err != nil {
+		return nil, "an unknown process", false
+	}
+
+	info := lockInfo{}
+	err = json.Unmarshal(raw, &info)
+
+	if err != nil {
+		return raw, "an unknown process", false
+	}
+
+	holder := fmt.Sprintf("pid %d on %s since %s", info.PID, info.Host, info.Started.Format("2006-01-02 15:04:05"))
+
+	return raw, holder, info.Host == host && !sys.ProcessExists(info.PID)
+}
+
+func releaseLock(sys dotf.SysOpsProvider, lockPath string) {
+	err := sys.RemoveFile(lockPath)
+
+	if err != nil {
+		sys.Warn(fmt.Sprintf("could not remove lock %s: %v\n", lockPath, err))
+	}
+}
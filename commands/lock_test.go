@@ -0,0 +1,181 @@
+package commands_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"bakku.dev/dotf/commands"
+	"bakku.dev/dotf/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+var lockTime = time.Date(2020, 11, 8, 10, 30, 0, 0, time.UTC)
+
+const lockContent = `{"pid":42,"host":"laptop","started":"2020-11-08T10:30:00Z"}`
+
+// expectLockSetup sets up building the lock path and the content of the lock
+// file of pid 42 on host laptop.
+func expectLockSetup(m *mocks.MockSysOpsProvider, times int) {
+	m.EXPECT().GetEnvVar("HOME").Return("/home/").Times(times)
+	m.EXPECT().GetPathSep().Return("/").Times(times)
+	m.EXPECT().CleanPath("/home//.dotf.lock").Return("/home/.dotf.lock").Times(times)
+	m.EXPECT().GetHostname().Return("laptop", nil).Times(times)
+	m.EXPECT().GetPid().Return(42).Times(times)
+}
+
+// expectLock sets up taking and releasing an unheld lock the given number of
+// times.
+func expectLock(m *mocks.MockSysOpsProvider, times int) {
+	expectLockSetup(m, times)
+	m.EXPECT().Now().Return(lockTime).Times(2 * times)
+	m.EXPECT().CreateLockFile("/home/.dotf.lock", []byte(lockContent)).Return(true, nil).Times(times)
+	m.EXPECT().RemoveFile("/home/.dotf.lock").Return(nil).Times(times)
+}
+
+func TestLock_ShouldCreateAndRemoveLockFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	expectLock(m, 1)
+
+	release, err := commands.Lock(m, commands.LockOptions{})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+
+	release()
+}
+
+func TestLock_ShouldFailIfLockIsHeldByRunningProcess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	expectLockSetup(m, 1)
+	m.EXPECT().Now().Return(lockTime).Times(2)
+	m.EXPECT().CreateLockFile("/home/.dotf.lock", gomock.Any()).Return(false, nil)
+	m.EXPECT().PathExists("/home/.dotf.lock").Return(true)
+	m.EXPECT().ReadFile("/home/.dotf.lock").Return([]byte(`{"pid":7,"host":"laptop","started":"2020-11-08T09:00:00Z"}`), nil)
+	m.EXPECT().ProcessExists(7).Return(true)
+
+	_, err := commands.Lock(m, commands.LockOptions{})
+
+	expected := "lock: /home/.dotf.lock is held by pid 7 on laptop since 2020-11-08 09:00:00, " +
+		"another dotf is running, retry with --wait to wait for it"
+
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected err to be %q, got %v", expected, err)
+	}
+}
+
+func TestLock_ShouldRemoveStaleLockOfThisHost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	expectLockSetup(m, 1)
+	m.EXPECT().Now().Return(lockTime).Times(2)
+
+	gomock.InOrder(
+		m.EXPECT().CreateLockFile("/home/.dotf.lock", []byte(lockContent)).Return(false, nil),
+		m.EXPECT().PathExists("/home/.dotf.lock").Return(true),
+		m.EXPECT().ReadFile("/home/.dotf.lock").Return([]byte(`{"pid":7,"host":"laptop","started":"2020-11-08T09:00:00Z"}`), nil),
+		m.EXPECT().ProcessExists(7).Return(false),
+		m.EXPECT().RemoveLockFile("/home/.dotf.lock", []byte(`{"pid":7,"host":"laptop","started":"2020-11-08T09:00:00Z"}`)).Return(true, nil),
+		m.EXPECT().Warn("removed stale lock /home/.dotf.lock of pid 7 on laptop since 2020-11-08 09:00:00\n"),
+		m.EXPECT().CreateLockFile("/home/.dotf.lock", []byte(lockContent)).Return(true, nil),
+	)
+
+	_, err := commands.Lock(m, commands.LockOptions{})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestLock_ShouldKeepLockTakenAgainAfterItWasFoundStale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	stale := []byte(`{"pid":7,"host":"laptop","started":"2020-11-08T09:00:00Z"}`)
+	taken := []byte(`{"pid":8,"host":"laptop","started":"2020-11-08T10:29:59Z"}`)
+
+	expectLockSetup(m, 1)
+	m.EXPECT().Now().Return(lockTime).Times(2)
+
+	gomock.InOrder(
+		m.EXPECT().CreateLockFile("/home/.dotf.lock", []byte(lockContent)).Return(false, nil),
+		m.EXPECT().PathExists("/home/.dotf.lock").Return(true),
+		m.EXPECT().ReadFile("/home/.dotf.lock").Return(stale, nil),
+		m.EXPECT().ProcessExists(7).Return(false),
+		// pid 8 removed the stale lock and took it before it could be removed
+		m.EXPECT().RemoveLockFile("/home/.dotf.lock", stale).Return(false, nil),
+		m.EXPECT().CreateLockFile("/home/.dotf.lock", []byte(lockContent)).Return(false, nil),
+		m.EXPECT().PathExists("/home/.dotf.lock").Return(true),
+		m.EXPECT().ReadFile("/home/.dotf.lock").Return(taken, nil),
+		m.EXPECT().ProcessExists(8).Return(true),
+	)
+
+	_, err := commands.Lock(m, commands.LockOptions{})
+
+	if err == nil || !strings.Contains(err.Error(), "held by pid 8 on laptop") {
+		t.Fatalf("Expected err about the lock of pid 8, got %v", err)
+	}
+}
+
+func TestLock_ShouldNotRemoveLockOfOtherHost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	expectLockSetup(m, 1)
+	m.EXPECT().Now().Return(lockTime).Times(2)
+	m.EXPECT().CreateLockFile("/home/.dotf.lock", gomock.Any()).Return(false, nil)
+	m.EXPECT().PathExists("/home/.dotf.lock").Return(true)
+	m.EXPECT().ReadFile("/home/.dotf.lock").Return([]byte(`{"pid":7,"host":"desktop","started":"2020-11-08T09:00:00Z"}`), nil)
+
+	_, err := commands.Lock(m, commands.LockOptions{})
+
+	if err == nil || !strings.Contains(err.Error(), "held by pid 7 on desktop") {
+		t.Fatalf("Expected err about the lock of desktop, got %v", err)
+	}
+}
+
+func TestLock_ShouldGiveUpAfterWaiting(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	expectLockSetup(m, 1)
+
+	gomock.InOrder(
+		m.EXPECT().Now().Return(lockTime),
+		m.EXPECT().Now().Return(lockTime),
+		m.EXPECT().Now().Return(lockTime.Add(500*time.Millisecond)),
+		m.EXPECT().Now().Return(lockTime.Add(time.Second)),
+	)
+
+	m.EXPECT().CreateLockFile("/home/.dotf.lock", gomock.Any()).Return(false, nil).Times(2)
+	m.EXPECT().PathExists("/home/.dotf.lock").Return(true).Times(2)
+	m.EXPECT().ReadFile("/home/.dotf.lock").Return([]byte(`{"pid":7,"host":"laptop","started":"2020-11-08T09:00:00Z"}`), nil).Times(2)
+	m.EXPECT().ProcessExists(7).Return(true).Times(2)
+	m.EXPECT().Sleep(500 * time.Millisecond)
+
+	_, err := commands.Lock(m, commands.LockOptions{Wait: time.Second})
+
+	expected := "lock: /home/.dotf.lock is still held by pid 7 on laptop since 2020-11-08 09:00:00 after waiting 1s"
+
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected err to be %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"bakku.dev/dotf"
+)
+
+// LogOptions configures the log command.
+type LogOptions struct {
+	// Verify shows the signature state of every commit.
+	Verify bool
+	// Output is the output format, OutputTable if it is empty.
+	Output string
+}
+
+// SignatureReport is the signature of a commit in the JSON output. State is
+// one of "unsigned", "good", "unknown key" or "bad".
+type SignatureReport struct {
+	State  string `json:"state"`
+	Signer string `json:"signer,omitempty"`
+}
+
+// CommitReport is a commit in the JSON output. Signature is only set if the
+// commits were verified.
+type CommitReport struct {
+	Hash      string           `json:"hash"`
+	Date      time.Time        `json:"date"`
+	Author    string           `json:"author"`
+	Message   string           `json:"message"`
+	Signature *SignatureReport `json:"signature,omitempty"`
+}
+
+// LogReport is the JSON output of the log command.
+type LogReport struct {
+	TrackedFileReport
+	Commits []CommitReport `json:"commits"`
+}
+
+// Log shows the commits which changed a tracked file.
+func Log(sys dotf.SysOpsProvider, systemFilePath string, opts LogOptions) error {
+	err := checkOutput(opts.Output)
+
+	if err != nil {
+		return fmt.Errorf("log: %v", err)
+	}
+
+	dotfilePath, err := getDotfConfigPath(sys)
+
+	if err != nil {
+		return fmt.Errorf("log: %v", err)
+	}
+
+	return showFileHistory(sys, dotfilePath, systemFilePath, opts)
+}
+
+func showFileHistory(sys dotf.SysOpsProvider, dotfilePath, systemFilePath string, opts LogOptions) error {
+	cfg, err := readConfig(sys, dotfilePath)
+
+	if err != nil {
+		return fmt.Errorf("log: %v", err)
+	}
+
+	tf, err := findTrackedFile(sys, cfg, systemFilePath)
+
+	if err != nil {
+		return fmt.Errorf("log: %v", err)
+	}
+
+	commits, err := sys.FileHistory(cfg.Repo, tf.PathInRepo)
+
+	if err != nil {
+		return fmt.Errorf("log: %v", err)
+	}
+
+	if len(commits) == 0 && opts.Output != OutputJSON && opts.Output != OutputPlain {
+		sys.Log(fmt.Sprintf("%s was never committed\n", tf.PathInRepo))
+
+		return nil
+	}
+
+	report := LogReport{
+		TrackedFileReport: TrackedFileReport{PathOnSystem: tf.PathOnSystem, PathInRepo: tf.PathInRepo},
+		Commits:           []CommitReport{},
+	}
+
+	header := []string{"Commit", "Date", "Author", "Message"}
+
+	if opts.Verify {
+		header = append(header, "Signature")
+	}
+
+	var rows [][]string
+
+	for _, commit := range commits {
+		commitReport := CommitReport{
+			Hash:    commit.Hash,
+			Date:    commit.When,
+			Author:  commit.Author,
+			Message: strings.TrimSpace(commit.Message),
+		}
+
+		row := []string{
+			shortHash(commit.Hash),
+			commit.When.Format("2006-01-02 15:04"),
+			commit.Author,
+			strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0],
+		}
+
+		if opts.Verify {
+			signature, err := sys.VerifyCommit(cfg.Repo, commit.Hash, cfg.Git)
+
+			if err != nil {
+				return fmt.Errorf("log: %v", err)
+			}
+
+			row = append(row, describeSignature(signature))
+			commitReport.Signature = &SignatureReport{State: signature.State, Signer: signature.Signer}
+		}
+
+		rows = append(rows, row)
+		report.Commits = append(report.Commits, commitReport)
+	}
+
+	if opts.Output == OutputJSON {
+		err = logJSON(sys, report)
+
+		if err != nil {
+			return fmt.Errorf("log: %v", err)
+		}
+
+		return nil
+	}
+
+	sys.Log(renderRows(opts.Output, header, rows, false))
+
+	return nil
+}
+
+// describeSignature formats the signature state of a commit for the log table.
+func describeSignature(signature dotf.Signature) string {
+	if signature.State == dotf.SignatureGood {
+		return fmt.Sprintf("%s (%s)", signature.State, signature.Signer)
+	}
+
+	return signature.State
+}
@@ -0,0 +1,271 @@
+package commands_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"bakku.dev/dotf"
+	"bakku.dev/dotf/commands"
+	"bakku.dev/dotf/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+func TestLog_ShouldFailIfNoHomeVarExists(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("")
+
+	err := commands.Log(m, "/home/.vimrc", commands.LogOptions{})
+
+	if err == nil {
+		t.Fatalf("Expected err to not be nil")
+	}
+}
+
+func TestLog_ShouldFailIfFileIsNotTracked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().ExpandPath(".bashrc").Return("/home/.bashrc", nil)
+
+	err := commands.Log(m, ".bashrc", commands.LogOptions{})
+
+	if err == nil {
+		t.Fatalf("Expected err not to be nil")
+	}
+}
+
+func TestLog_ShouldFailIfHistoryCannotBeRead(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().ExpandPath("/home/.vimrc").Return("/home/.vimrc", nil)
+	m.EXPECT().FileHistory("/home/repo", ".vimrc").Return(nil, errors.New("error"))
+
+	err := commands.Log(m, "/home/.vimrc", commands.LogOptions{})
+
+	if err == nil {
+		t.Fatalf("Expected err not to be nil")
+	}
+}
+
+func TestLog_ShouldListCommitsOfFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	commits := []dotf.Commit{
+		{
+			Hash:    "8f3a2c1d9e0b7a6f5c4d3e2f1a0b9c8d7e6f5a4b",
+			Author:  "jane",
+			When:    time.Date(2020, 11, 2, 9, 15, 0, 0, time.UTC),
+			Message: "Enable line numbers\n\nThey help with pairing.\n",
+		},
+		{
+			Hash:    "1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e",
+			Author:  "john",
+			When:    time.Date(2020, 10, 30, 18, 0, 0, 0, time.UTC),
+			Message: "Add .vimrc",
+		},
+	}
+
+	expectedTableString := "" +
+		"+---------+------------------+--------+---------------------+\n" +
+		"| COMMIT  |       DATE       | AUTHOR |       MESSAGE       |\n" +
+		"+---------+------------------+--------+---------------------+\n" +
+		"| 8f3a2c1 | 2020-11-02 09:15 | jane   | Enable line numbers |\n" +
+		"| 1b2c3d4 | 2020-10-30 18:00 | john   | Add .vimrc          |\n" +
+		"+---------+------------------+--------+---------------------+\n"
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().ExpandPath("/home/.vimrc").Return("/home/.vimrc", nil)
+	m.EXPECT().FileHistory("/home/repo", ".vimrc").Return(commits, nil)
+	m.EXPECT().Log(expectedTableString)
+
+	err := commands.Log(m, "/home/.vimrc", commands.LogOptions{})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestLog_ShouldShowSignatureStates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+		Git: dotf.GitConfig{SigningKey: "/home/.dotf.asc"},
+	}
+
+	commits := []dotf.Commit{
+		{
+			Hash:    "8f3a2c1d9e0b7a6f5c4d3e2f1a0b9c8d7e6f5a4b",
+			Author:  "jane",
+			When:    time.Date(2020, 11, 2, 9, 15, 0, 0, time.UTC),
+			Message: "Enable line numbers",
+		},
+		{
+			Hash:    "1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e",
+			Author:  "john",
+			When:    time.Date(2020, 10, 30, 18, 0, 0, 0, time.UTC),
+			Message: "Add .vimrc",
+		},
+	}
+
+	expectedTableString := "" +
+		"+---------+------------------+--------+---------------------+------------------------+\n" +
+		"| COMMIT  |       DATE       | AUTHOR |       MESSAGE       |       SIGNATURE        |\n" +
+		"+---------+------------------+--------+---------------------+------------------------+\n" +
+		"| 8f3a2c1 | 2020-11-02 09:15 | jane   | Enable line numbers | good (jane <jane@doe>) |\n" +
+		"| 1b2c3d4 | 2020-10-30 18:00 | john   | Add .vimrc          | unsigned               |\n" +
+		"+---------+------------------+--------+---------------------+------------------------+\n"
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().ExpandPath("/home/.vimrc").Return("/home/.vimrc", nil)
+	m.EXPECT().FileHistory("/home/repo", ".vimrc").Return(commits, nil)
+	m.EXPECT().
+		VerifyCommit("/home/repo", commits[0].Hash, cfg.Git).
+		Return(dotf.Signature{State: dotf.SignatureGood, Signer: "jane <jane@doe>"}, nil)
+	m.EXPECT().
+		VerifyCommit("/home/repo", commits[1].Hash, cfg.Git).
+		Return(dotf.Signature{State: dotf.SignatureNone}, nil)
+	m.EXPECT().Log(expectedTableString)
+
+	err := commands.Log(m, "/home/.vimrc", commands.LogOptions{Verify: true})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestLog_ShouldShowJSONReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	commits := []dotf.Commit{
+		{
+			Hash:    "8f3a2c1d9e0b7a6f5c4d3e2f1a0b9c8d7e6f5a4b",
+			Author:  "jane",
+			When:    time.Date(2020, 11, 2, 9, 15, 0, 0, time.UTC),
+			Message: "Enable line numbers\n\nThey help with pairing.\n",
+		},
+	}
+
+	expected := "{\n" +
+		"  \"pathOnSystem\": \"/home/.vimrc\",\n" +
+		"  \"pathInRepo\": \".vimrc\",\n" +
+		"  \"commits\": [\n" +
+		"    {\n" +
+		"      \"hash\": \"8f3a2c1d9e0b7a6f5c4d3e2f1a0b9c8d7e6f5a4b\",\n" +
+		"      \"date\": \"2020-11-02T09:15:00Z\",\n" +
+		"      \"author\": \"jane\",\n" +
+		"      \"message\": \"Enable line numbers\\n\\nThey help with pairing.\",\n" +
+		"      \"signature\": {\n" +
+		"        \"state\": \"good\",\n" +
+		"        \"signer\": \"Jane Doe \\u003cjane@example.com\\u003e\"\n" +
+		"      }\n" +
+		"    }\n" +
+		"  ]\n" +
+		"}\n"
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().ExpandPath("/home/.vimrc").Return("/home/.vimrc", nil)
+	m.EXPECT().FileHistory("/home/repo", ".vimrc").Return(commits, nil)
+	m.EXPECT().
+		VerifyCommit("/home/repo", commits[0].Hash, dotf.GitConfig{}).
+		Return(dotf.Signature{State: dotf.SignatureGood, Signer: "Jane Doe <jane@example.com>"}, nil)
+	m.EXPECT().Log(expected)
+
+	err := commands.Log(m, "/home/.vimrc", commands.LogOptions{Verify: true, Output: commands.OutputJSON})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
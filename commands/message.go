@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"bakku.dev/dotf"
+)
+
+const defaultCommitTemplate = `Update dotfiles from {{.Hostname}} at {{.Time.Format "2006-01-02 15:04:05"}}
+{{range .Added}}
+added: {{.}}{{end}}{{range .Modified}}
+modified: {{.}}{{end}}{{range .Deleted}}
+deleted: {{.}}{{end}}`
+
+// commitMessageData is passed to the commit message template.
+type commitMessageData struct {
+	dotf.FileChanges
+	Hostname string
+	Time     time.Time
+}
+
+func generateCommitMessage(sys dotf.SysOpsProvider, cfg dotf.Config, changes dotf.FileChanges) (string, error) {
+	text := cfg.CommitTemplate
+
+	if text == "" {
+		text = defaultCommitTemplate
+	}
+
+	tmpl, err := template.New("commit").Parse(text)
+
+	if err != nil {
+		return "", fmt.Errorf("invalid commit message template: %v", err)
+	}
+
+	hostname, err := sys.GetHostname()
+
+	if err != nil {
+		return "", fmt.Errorf("could not determine hostname: %v", err)
+	}
+
+	data := commitMessageData{FileChanges: changes, Hostname: hostname, Time: sys.Now()}
+	builder := &strings.Builder{}
+
+	err = tmpl.Execute(builder, data)
+
+	if err != nil {
+		return "", fmt.Errorf("could not render commit message template: %v", err)
+	}
+
+	return strings.TrimSpace(builder.String()), nil
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"bakku.dev/dotf"
+	"github.com/olekukonko/tablewriter"
+)
+
+// Output formats of the reporting commands list, status, log and snapshot
+// list. The JSON documents are described by the *Report types and in
+// docs/output.md, their fields are only ever added to.
+const (
+	// OutputTable renders ASCII tables, it is used if no format is given.
+	OutputTable = "table"
+	// OutputPlain renders one line of tab separated columns per table row
+	// without header.
+	OutputPlain = "plain"
+	// OutputJSON renders a single JSON document.
+	OutputJSON = "json"
+)
+
+// checkOutput returns an error if output is no known output format.
+func checkOutput(output string) error {
+	switch output {
+	case "", OutputTable, OutputPlain, OutputJSON:
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %s, use %s, %s or %s", output, OutputTable, OutputPlain, OutputJSON)
+	}
+}
+
+// renderRows renders rows as ASCII table with header, or as tab separated
+// lines for OutputPlain. Long cells are wrapped in tables if wrap is set.
+func renderRows(output string, header []string, rows [][]string, wrap bool) string {
+	stringBuilder := &strings.Builder{}
+
+	if output == OutputPlain {
+		for _, row := range rows {
+			stringBuilder.WriteString(strings.Join(row, "\t") + "\n")
+		}
+
+		return stringBuilder.String()
+	}
+
+	table := tablewriter.NewWriter(stringBuilder)
+	table.SetHeader(header)
+	table.SetAutoWrapText(wrap)
+	table.AppendBulk(rows)
+	table.Render()
+
+	return stringBuilder.String()
+}
+
+// logJSON logs report as indented JSON document.
+func logJSON(sys dotf.SysOpsProvider, report interface{}) error {
+	raw, err := json.MarshalIndent(report, "", "  ")
+
+	if err != nil {
+		return fmt.Errorf("could not serialize output: %v", err)
+	}
+
+	sys.Log(string(raw) + "\n")
+
+	return nil
+}
+
+// TrackedFileReport is a tracked file in the JSON output.
+type TrackedFileReport struct {
+	PathOnSystem string `json:"pathOnSystem"`
+	PathInRepo   string `json:"pathInRepo"`
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultJobs is how many tracked files are copied, compared or validated at
+// the same time if no number of jobs is given.
+const DefaultJobs = 8
+
+// forEachParallel calls work for the indices 0 to n-1 with at most jobs calls
+// running at the same time. If jobs is zero or less DefaultJobs is used. No
+// further work is started once a call failed. The error of the lowest failed
+// index is returned, so it does not depend on the order the calls finish in.
+// Results of work should be stored by index for the same reason.
+func forEachParallel(jobs, n int, work func(i int) error) error {
+	if jobs <= 0 {
+		jobs = DefaultJobs
+	}
+
+	if jobs > n {
+		jobs = n
+	}
+
+	errs := make([]error, n)
+	indices := make(chan int)
+
+	var (
+		failed int32
+		wg     sync.WaitGroup
+	)
+
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indices {
+				errs[i] = work(i)
+
+				if errs[i] != nil {
+					atomic.StoreInt32(&failed, 1)
+				}
+			}
+		}()
+	}
+
+	// indices are handed out in order, so every index below a failed one was
+	// already started when dispatching stops
+	for i := 0; i < n && atomic.LoadInt32(&failed) == 0; i++ {
+		indices <- i
+	}
+
+	close(indices)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package commands_test
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bakku.dev/dotf"
+	"bakku.dev/dotf/commands"
+	"bakku.dev/dotf/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+// manyTrackedFiles returns n tracked files named file00, file01 and so on.
+func manyTrackedFiles(n int) []dotf.TrackedFile {
+	var files []dotf.TrackedFile
+
+	for i := 0; i < n; i++ {
+		files = append(files, dotf.TrackedFile{
+			PathInRepo:   fmt.Sprintf("/file%02d", i),
+			PathOnSystem: fmt.Sprintf("/home/file%02d", i),
+		})
+	}
+
+	return files
+}
+
+func TestPush_ShouldValidateInParallelAndReportFailuresInOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	const jobs = 4
+
+	cfg := dotf.Config{
+		Repo:         "/home/repo",
+		TrackedFiles: manyTrackedFiles(12),
+		Validators:   []dotf.Validator{{Match: "file*", Command: "check"}},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(0, nil)
+
+	var running, maxRunning int32
+
+	expected := "push: invalid tracked files, nothing was copied to the repo:\n"
+
+	for i, tf := range cfg.TrackedFiles {
+		content := []byte(tf.PathOnSystem)
+		delay := time.Duration(len(cfg.TrackedFiles)-i) * time.Millisecond
+
+		m.EXPECT().PathExists(tf.PathOnSystem).Return(true)
+		m.EXPECT().ReadFile(tf.PathOnSystem).Return(content, nil)
+		m.EXPECT().
+			RunCommand(dotf.Command{Line: "check", Stdin: content}).
+			DoAndReturn(func(dotf.Command) ([]byte, int, error) {
+				now := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+
+				for {
+					max := atomic.LoadInt32(&maxRunning)
+
+					if now <= max || atomic.CompareAndSwapInt32(&maxRunning, max, now) {
+						break
+					}
+				}
+
+				time.Sleep(delay)
+
+				return []byte("rejected\n"), 1, nil
+			})
+
+		expected += fmt.Sprintf("  %s (check): exit code 1\n    rejected\n", tf.PathOnSystem)
+	}
+
+	err := commands.Push(m, commands.PushOptions{Jobs: jobs})
+
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected err to be\n%s\ngot\n%v", expected, err)
+	}
+
+	if maxRunning > jobs {
+		t.Fatalf("Expected at most %d validators to run at the same time, got %d", jobs, maxRunning)
+	}
+}
+
+func TestPull_ShouldReportErrorOfFirstFailingFileWhenDeployingInParallel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo:         "/home/repo",
+		TrackedFiles: manyTrackedFiles(12),
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().DirtyFiles("/home/repo").Return(nil, nil)
+	m.EXPECT().UpdateRepo("/home/repo", dotf.GitConfig{}).Return(nil)
+
+	files := make(map[string]string)
+
+	for _, tf := range cfg.TrackedFiles {
+		files[tf.PathInRepo] = "content"
+	}
+
+	expectRepoReader(ctrl, m, "HEAD", files)
+
+	// file03 fails after file07 did, still its error is reported
+	m.EXPECT().WriteFile("/home/file03", []byte("content")).DoAndReturn(func(string, []byte) error {
+		time.Sleep(20 * time.Millisecond)
+
+		return errors.New("file03 is read-only")
+	})
+	m.EXPECT().WriteFile("/home/file07", []byte("content")).Return(errors.New("file07 is read-only")).MaxTimes(1)
+	m.EXPECT().WriteFile(gomock.Any(), []byte("content")).Return(nil).AnyTimes()
+
+	err := commands.Pull(m, commands.PullOptions{Jobs: 8})
+
+	if err == nil || err.Error() != "pull: file03 is read-only" {
+		t.Fatalf("Expected error of file03, got %v", err)
+	}
+}
+
+func TestPush_ShouldCopyFilesOneAfterAnotherWithOneJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo:         "/home/repo",
+		TrackedFiles: manyTrackedFiles(3),
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/").AnyTimes()
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(0, nil)
+
+	var copies []*gomock.Call
+	var paths []string
+
+	for _, tf := range cfg.TrackedFiles {
+		m.EXPECT().CleanPath("/home/repo/" + tf.PathInRepo).Return("/home/repo" + tf.PathInRepo)
+		copies = append(copies, m.EXPECT().CopyFile(tf.PathOnSystem, "/home/repo"+tf.PathInRepo).Return(nil))
+		paths = append(paths, tf.PathInRepo)
+	}
+
+	gomock.InOrder(copies...)
+
+	m.EXPECT().RepoChanges("/home/repo", paths).Return(dotf.FileChanges{}, nil)
+	m.EXPECT().Log("nothing to push\n")
+
+	err := commands.Push(m, commands.PushOptions{Jobs: 1})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
@@ -1,56 +1,298 @@
 package commands
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"strings"
 
 	"bakku.dev/dotf"
 )
 
+// Ways to handle uncommitted changes in the repo before pulling.
+const (
+	DirtyAbort  = "abort"
+	DirtyCommit = "commit"
+	DirtyStash  = "stash"
+)
+
+const stashDirName = ".dotf-stash"
+
+// PullOptions configures the pull command.
+type PullOptions struct {
+	// Strategy overrides the pull strategy of the config.
+	Strategy string
+	// Dirty decides what happens to uncommitted changes in the repo. Pull
+	// aborts by default.
+	Dirty string
+	// Rev deploys the tracked files as they were in the given commit, tag or
+	// branch instead of updating the repo.
+	Rev string
+	// Jobs is how many tracked files are validated and deployed at the same
+	// time. DefaultJobs is used if it is zero.
+	Jobs int
+}
+
 // Pull updates the repository and replaces all files with newly pulled ones.
-func Pull(sys dotf.SysOpsProvider) error {
+func Pull(sys dotf.SysOpsProvider, opts PullOptions) error {
 	dotfilePath, err := getDotfConfigPath(sys)
 
 	if err != nil {
 		return fmt.Errorf("pull: %v", err)
 	}
 
-	return updateDotfiles(sys, dotfilePath)
+	return updateDotfiles(sys, dotfilePath, opts)
 }
 
-func updateDotfiles(sys dotf.SysOpsProvider, dotfilePath string) error {
+func updateDotfiles(sys dotf.SysOpsProvider, dotfilePath string, opts PullOptions) error {
 	cfg, err := readConfig(sys, dotfilePath)
 
 	if err != nil {
 		return fmt.Errorf("pull: %v", err)
 	}
 
-	err = sys.UpdateRepo(cfg.Repo)
+	err = runPreHook(sys, cfg, operationPull, hookPrePull, cfg.Hooks.PrePull)
+
+	if err != nil {
+		return fmt.Errorf("pull: %v", err)
+	}
+
+	if opts.Rev != "" {
+		err = sys.FetchRepo(cfg.Repo, cfg.Git)
+
+		if err != nil {
+			return fmt.Errorf("pull: %v", err)
+		}
+
+		revision, changed, err := deployRevision(sys, dotfilePath, cfg, opts.Rev, operationPull, opts.Jobs)
+
+		if err != nil {
+			return fmt.Errorf("pull: %v", err)
+		}
+
+		sys.Log(fmt.Sprintf("deployed %s (%s)\n", opts.Rev, revision))
+		runPostHook(sys, cfg, operationPull, hookPostPull, cfg.Hooks.PostPull, changed)
+
+		return nil
+	}
+
+	if opts.Strategy != "" {
+		cfg.Git.PullStrategy = opts.Strategy
+	}
+
+	err = cleanRepo(sys, cfg, opts.Dirty)
+
+	if err != nil {
+		return fmt.Errorf("pull: %v", err)
+	}
+
+	err = sys.UpdateRepo(cfg.Repo, cfg.Git)
 
 	if err != nil {
 		return fmt.Errorf("pull: %v", err)
 	}
 
+	_, changed, err := deployRevision(sys, dotfilePath, cfg, "HEAD", operationPull, opts.Jobs)
+
+	if err != nil {
+		return fmt.Errorf("pull: %v", err)
+	}
+
+	runPostHook(sys, cfg, operationPull, hookPostPull, cfg.Hooks.PostPull, changed)
+
+	return nil
+}
+
+// deployRevision copies the tracked files as they were in rev onto the system
+// and records the deployed commit in the dotf config. The worktree of the repo
+// is not touched. It returns the deployed commit and the tracked files whose
+// content changed, which are only detected if a hook needs them. Up to jobs
+// files are deployed at the same time.
+func deployRevision(sys dotf.SysOpsProvider, dotfilePath string, cfg dotf.Config, rev, operation string, jobs int) (string, []dotf.TrackedFile, error) {
+	revision, err := sys.ResolveRevision(cfg.Repo, rev)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	reader, err := sys.OpenRepoReader(cfg.Repo, revision)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	changed, err := deployTrackedFiles(sys, cfg, reader, operation, jobs)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = recordDeployedRevision(sys, dotfilePath, revision)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return revision, changed, nil
+}
+
+// recordDeployedRevision stores which commit is deployed on the system in the
+// dotf config.
+func recordDeployedRevision(sys dotf.SysOpsProvider, dotfilePath, revision string) error {
+	return updateConfig(sys, dotfilePath, func(cfg *dotf.Config) error {
+		cfg.DeployedRevision = revision
+
+		return nil
+	})
+}
+
+// deployedFile is a tracked file with its content read from the repo.
+type deployedFile struct {
+	tf      dotf.TrackedFile
+	content []byte
+}
+
+// deployTrackedFiles writes all tracked files served by reader onto the
+// system. Files which do not exist in the repo are skipped. Nothing is written
+// if a validator rejects one of the files. The files are read from the repo
+// one after another, validating, comparing and writing them runs for up to jobs
+// files at the same time. The onChange hooks of files whose content changed
+// run after all files are written.
+func deployTrackedFiles(sys dotf.SysOpsProvider, cfg dotf.Config, reader dotf.RepoReader, operation string, jobs int) ([]dotf.TrackedFile, error) {
+	var files []deployedFile
+
 	for _, tf := range cfg.TrackedFiles {
+		content, err := reader.ReadFile(tf.PathInRepo)
+
+		if errors.Is(err, dotf.ErrNotInRepo) {
+			sys.Log(fmt.Sprintf("skipping %s, %s does not exist in the repo\n", tf.PathOnSystem, tf.PathInRepo))
+
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, deployedFile{tf: tf, content: content})
+	}
+
+	err := validateRepoFiles(sys, cfg, files, jobs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	isChanged := make([]bool, len(files))
+
+	err = forEachParallel(jobs, len(files), func(i int) error {
+		file := files[i]
+
+		if file.tf.OnChange != "" || cfg.Hooks.PostPull != "" {
+			current, err := readSystemFile(sys, file.tf.PathOnSystem)
+
+			if err != nil {
+				return err
+			}
+
+			isChanged[i] = current == nil || !bytes.Equal(current, file.content)
+		}
+
 		if cfg.CreateBackups {
-			err = sys.CopyFile(
-				tf.PathOnSystem,
-				tf.PathOnSystem+".bk",
+			err := sys.CopyFile(
+				file.tf.PathOnSystem,
+				file.tf.PathOnSystem+".bk",
 			)
 
 			if err != nil {
-				return fmt.Errorf("pull: %v", err)
+				return err
 			}
 		}
 
-		err = sys.CopyFile(
-			sys.CleanPath(cfg.Repo+sys.GetPathSep()+tf.PathInRepo),
-			tf.PathOnSystem,
+		return sys.WriteFile(file.tf.PathOnSystem, file.content)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []dotf.TrackedFile
+
+	for i, file := range files {
+		if isChanged[i] {
+			changed = append(changed, file.tf)
+		}
+	}
+
+	runChangeHooks(sys, cfg, operation, changed)
+
+	return changed, nil
+}
+
+// cleanRepo makes sure the repo contains no uncommitted changes which could
+// be overwritten by pulling or deployed onto the system unintentionally.
+func cleanRepo(sys dotf.SysOpsProvider, cfg dotf.Config, dirty string) error {
+	files, err := sys.DirtyFiles(cfg.Repo)
+
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		return nil
+	}
+
+	switch dirty {
+	case "", DirtyAbort:
+		return fmt.Errorf(
+			"repo %s contains uncommitted changes:\n  %s\nrun pull with --dirty %s or --dirty %s to keep them",
+			cfg.Repo, strings.Join(files, "\n  "), DirtyCommit, DirtyStash,
 		)
+	case DirtyCommit:
+		findings, err := findSecrets(sys, cfg, files)
 
 		if err != nil {
-			return fmt.Errorf("pull: %v", err)
+			return err
+		}
+
+		if len(findings) > 0 {
+			return secretsFoundError(findings)
+		}
+
+		return sys.CommitRepo(cfg.Repo, "Commit uncommitted repo changes before pull", files, cfg.Git)
+	case DirtyStash:
+		return stashFiles(sys, cfg, files)
+	default:
+		return fmt.Errorf("unknown value %s for --dirty", dirty)
+	}
+}
+
+// stashFiles copies the given repo files to a new directory below
+// $HOME/.dotf-stash and discards their changes in the repo afterwards.
+func stashFiles(sys dotf.SysOpsProvider, cfg dotf.Config, files []string) error {
+	sep := sys.GetPathSep()
+	stashPath := sys.CleanPath(
+		sys.GetEnvVar("HOME") + sep + stashDirName + sep + sys.Now().Format("20060102-150405"),
+	)
+
+	for _, file := range files {
+		err := sys.CopyFile(
+			sys.CleanPath(cfg.Repo+sep+file),
+			sys.CleanPath(stashPath+sep+file),
+		)
+
+		if err != nil {
+			return err
 		}
 	}
 
+	err := sys.DiscardChanges(cfg.Repo, files)
+
+	if err != nil {
+		return err
+	}
+
+	sys.Log(fmt.Sprintf("stashed uncommitted repo changes in %s\n", stashPath))
+
 	return nil
 }
@@ -1,45 +1,434 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"bakku.dev/dotf"
 )
 
-// Push copies all file to the repo, commits and pushes it.
-func Push(sys dotf.SysOpsProvider, message string) error {
+// PushOptions configures the push command.
+type PushOptions struct {
+	// Message is used as commit message. If it is empty a message describing
+	// the changes is generated.
+	Message string
+	// NoPush only commits the changes locally. They are pushed together with
+	// the next push.
+	NoPush bool
+	// Confirm shows the changes and asks before committing them. Declined
+	// changes are removed from the repo again.
+	Confirm bool
+	// Jobs is how many tracked files are validated and copied at the same
+	// time. DefaultJobs is used if it is zero.
+	Jobs int
+}
+
+// Push copies all file to the repo, commits and pushes it. Commits which were
+// not pushed before are pushed first.
+func Push(sys dotf.SysOpsProvider, opts PushOptions) error {
 	dotfilePath, err := getDotfConfigPath(sys)
 
 	if err != nil {
 		return fmt.Errorf("push: %v", err)
 	}
 
-	return pushDotfiles(sys, dotfilePath, message)
+	return pushDotfiles(sys, dotfilePath, opts)
 }
 
-func pushDotfiles(sys dotf.SysOpsProvider, dotfilePath, message string) error {
+func pushDotfiles(sys dotf.SysOpsProvider, dotfilePath string, opts PushOptions) error {
 	cfg, err := readConfig(sys, dotfilePath)
 
 	if err != nil {
 		return fmt.Errorf("push: %v", err)
 	}
 
-	for _, tf := range cfg.TrackedFiles {
-		err = sys.CopyFile(
-			tf.PathOnSystem,
-			sys.CleanPath(cfg.Repo+sys.GetPathSep()+tf.PathInRepo),
-		)
+	err = runPreHook(sys, cfg, operationPush, hookPrePush, cfg.Hooks.PrePush)
+
+	if err != nil {
+		return fmt.Errorf("push: %v", err)
+	}
+
+	done, changed, err := pushChanges(sys, cfg, opts)
+
+	if err != nil {
+		return fmt.Errorf("push: %v", err)
+	}
+
+	if done {
+		runPostHook(sys, cfg, operationPush, hookPostPush, cfg.Hooks.PostPush, changed)
+	}
+
+	return nil
+}
+
+// pushChanges copies, commits and pushes the tracked files. It returns false
+// if the push was cancelled and the tracked files which were committed. With
+// Confirm, queued commits are only pushed together with the confirmed changes.
+func pushChanges(sys dotf.SysOpsProvider, cfg dotf.Config, opts PushOptions) (bool, []dotf.TrackedFile, error) {
+	if opts.Confirm {
+		return pushConfirmedChanges(sys, cfg, opts)
+	}
+
+	if !opts.NoPush {
+		err := pushQueuedCommits(sys, cfg)
 
 		if err != nil {
-			return fmt.Errorf("push: %v", err)
+			return false, nil, err
 		}
 	}
 
-	err = sys.CommitRepo(cfg.Repo, message)
+	files, err := copyToRepo(sys, cfg, opts.Jobs)
 
 	if err != nil {
-		return fmt.Errorf("push: %v", err)
+		return false, nil, err
+	}
+
+	return commitAndPush(sys, cfg, files, opts, 0)
+}
+
+// pushConfirmedChanges copies the tracked files into the repo and asks
+// whether they should be pushed together with the queued commits. Declined
+// copies are undone by writing back the repo files as they were before.
+func pushConfirmedChanges(sys dotf.SysOpsProvider, cfg dotf.Config, opts PushOptions) (bool, []dotf.TrackedFile, error) {
+	var queued []dotf.Commit
+
+	if !opts.NoPush {
+		var err error
+		queued, err = sys.ListUnpushedCommits(cfg.Repo, cfg.Git)
+
+		if err != nil {
+			return false, nil, err
+		}
+	}
+
+	previous, err := readRepoCopies(sys, cfg)
+
+	if err != nil {
+		return false, nil, err
+	}
+
+	files, err := copyToRepo(sys, cfg, opts.Jobs)
+
+	if err != nil {
+		return false, nil, cancelConfirmedPush(sys, previous, err)
+	}
+
+	confirmed, err := confirmChanges(sys, cfg, files, queued)
+
+	if err != nil {
+		return false, nil, cancelConfirmedPush(sys, previous, err)
+	}
+
+	if !confirmed {
+		err = cancelConfirmedPush(sys, previous, nil)
+
+		if err != nil {
+			return false, nil, err
+		}
+
+		sys.Log("push cancelled, the repo is unchanged\n")
+
+		return false, nil, nil
+	}
+
+	changes, err := commitToRepo(sys, cfg, files, opts.Message)
+
+	if err != nil {
+		return false, nil, cancelConfirmedPush(sys, previous, err)
+	}
+
+	return pushCommitted(sys, cfg, changes, opts, len(queued))
+}
+
+// cancelConfirmedPush restores the repo copies read before a confirmed push
+// because nothing was committed. It returns cause, which is nil if the push
+// was declined, together with an error of restoring the copies.
+func cancelConfirmedPush(sys dotf.SysOpsProvider, previous map[string][]byte, cause error) error {
+	err := restoreRepoCopies(sys, previous)
+
+	switch {
+	case err == nil:
+		return cause
+	case cause == nil:
+		return err
+	default:
+		return fmt.Errorf("%v, the repo could not be restored either: %v", cause, err)
+	}
+}
+
+// commitAndPush commits the copied files and pushes them together with queued
+// commits which were not pushed yet, unless NoPush is set.
+func commitAndPush(sys dotf.SysOpsProvider, cfg dotf.Config, files []string, opts PushOptions, queued int) (bool, []dotf.TrackedFile, error) {
+	changes, err := commitToRepo(sys, cfg, files, opts.Message)
+
+	if err != nil {
+		return false, nil, err
+	}
+
+	return pushCommitted(sys, cfg, changes, opts, queued)
+}
+
+// pushCommitted pushes the committed changes together with queued commits,
+// unless NoPush is set.
+func pushCommitted(sys dotf.SysOpsProvider, cfg dotf.Config, changes dotf.FileChanges, opts PushOptions, queued int) (bool, []dotf.TrackedFile, error) {
+	if changes.Empty() && queued == 0 {
+		sys.Log("nothing to push\n")
+
+		return true, nil, nil
+	}
+
+	if opts.NoPush {
+		sys.Log("committed changes locally, they will be pushed with the next push\n")
+
+		return true, committedFiles(cfg, changes), nil
+	}
+
+	err := sys.PushRepo(cfg.Repo, cfg.Git)
+
+	if err != nil {
+		return false, nil, err
+	}
+
+	return true, committedFiles(cfg, changes), nil
+}
+
+// copyToRepo copies all tracked files from the system into the repo and
+// returns their paths in the repo. Nothing is copied if a validator rejects
+// one of the files.
+func copyToRepo(sys dotf.SysOpsProvider, cfg dotf.Config, jobs int) ([]string, error) {
+	err := validateSystemFiles(sys, cfg, jobs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var files, destinations []string
+
+	for _, tf := range cfg.TrackedFiles {
+		files = append(files, tf.PathInRepo)
+		destinations = append(destinations, sys.CleanPath(cfg.Repo+sys.GetPathSep()+tf.PathInRepo))
+	}
+
+	err = forEachParallel(jobs, len(cfg.TrackedFiles), func(i int) error {
+		return sys.CopyFile(cfg.TrackedFiles[i].PathOnSystem, destinations[i])
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// confirmChanges lists the queued commits and the changes copied into the
+// repo with diff stats and asks whether they should be pushed. The full diff
+// is shown on request.
+func confirmChanges(sys dotf.SysOpsProvider, cfg dotf.Config, files []string, queued []dotf.Commit) (bool, error) {
+	changes, err := sys.RepoChanges(cfg.Repo, files)
+
+	if err != nil {
+		return false, err
+	}
+
+	if changes.Empty() && len(queued) == 0 {
+		return true, nil
+	}
+
+	committed, err := sys.OpenRepoReader(cfg.Repo, "HEAD")
+
+	if err != nil {
+		return false, err
+	}
+
+	staged, err := sys.OpenRepoReader(cfg.Repo, "")
+
+	if err != nil {
+		return false, err
+	}
+
+	summary := &strings.Builder{}
+	diff := &strings.Builder{}
+
+	if len(queued) > 0 {
+		summary.WriteString("queued commits to push:\n")
+
+		for _, commit := range queued {
+			summary.WriteString(fmt.Sprintf(
+				"  %s %s\n", shortHash(commit.Hash), strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0],
+			))
+		}
+	}
+
+	if !changes.Empty() {
+		summary.WriteString("changes to push:\n")
+	}
+
+	states := []struct {
+		name  string
+		files []string
+	}{
+		{"added", changes.Added},
+		{"modified", changes.Modified},
+		{"deleted", changes.Deleted},
+	}
+
+	for _, state := range states {
+		for _, file := range state.files {
+			oldContent, err := readRepoFile(committed, file)
+
+			if err != nil {
+				return false, err
+			}
+
+			newContent, err := readRepoFile(staged, file)
+
+			if err != nil {
+				return false, err
+			}
+
+			added, removed := diffStat(oldContent, newContent)
+			summary.WriteString(fmt.Sprintf("  %-8s %s (+%d -%d)\n", state.name, file, added, removed))
+
+			fromName, toName := "a/"+file, "b/"+file
+
+			if oldContent == nil {
+				fromName = "/dev/null"
+			}
+
+			if newContent == nil {
+				toName = "/dev/null"
+			}
+
+			diff.WriteString(unifiedDiff(fromName, toName, oldContent, newContent))
+		}
+	}
+
+	sys.Log(summary.String())
+
+	for {
+		sys.Log("push these changes? [y]es, [n]o, show [d]iff: ")
+
+		answer, err := sys.ReadLine()
+
+		if err != nil {
+			return false, err
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return true, nil
+		case "d", "diff":
+			sys.Log(diff.String())
+		default:
+			return false, nil
+		}
+	}
+}
+
+// readRepoCopies reads the repo copies of all tracked files before they are
+// overwritten, keyed by their path. Missing copies are nil.
+func readRepoCopies(sys dotf.SysOpsProvider, cfg dotf.Config) (map[string][]byte, error) {
+	copies := make(map[string][]byte)
+
+	for _, tf := range cfg.TrackedFiles {
+		path := sys.CleanPath(cfg.Repo + sys.GetPathSep() + tf.PathInRepo)
+		content, err := readSystemFile(sys, path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		copies[path] = content
+	}
+
+	return copies, nil
+}
+
+// restoreRepoCopies writes back the repo copies read by readRepoCopies and
+// removes copies which did not exist before. Uncommitted edits in the repo are
+// kept this way.
+func restoreRepoCopies(sys dotf.SysOpsProvider, copies map[string][]byte) error {
+	var paths []string
+
+	for path := range copies {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		var err error
+
+		if copies[path] == nil {
+			err = sys.RemoveFile(path)
+		} else {
+			err = sys.WriteFile(path, copies[path])
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not restore %s: %v", path, err)
+		}
 	}
 
 	return nil
 }
+
+// commitToRepo scans the changed repo files for secrets and commits them. If
+// message is empty a message describing the changes is generated. It returns
+// the committed changes, which are empty if nothing changed.
+func commitToRepo(sys dotf.SysOpsProvider, cfg dotf.Config, files []string, message string) (dotf.FileChanges, error) {
+	changes, err := sys.RepoChanges(cfg.Repo, files)
+
+	if err != nil {
+		return dotf.FileChanges{}, err
+	}
+
+	if changes.Empty() {
+		return dotf.FileChanges{}, nil
+	}
+
+	err = scanForSecrets(sys, cfg, changes)
+
+	if err != nil {
+		return dotf.FileChanges{}, err
+	}
+
+	if message == "" {
+		message, err = generateCommitMessage(sys, cfg, changes)
+
+		if err != nil {
+			return dotf.FileChanges{}, err
+		}
+	}
+
+	err = sys.CommitRepo(cfg.Repo, message, files, cfg.Git)
+
+	if errors.Is(err, dotf.ErrNothingToCommit) {
+		return dotf.FileChanges{}, nil
+	}
+
+	if err != nil {
+		return dotf.FileChanges{}, err
+	}
+
+	return changes, nil
+}
+
+// pushQueuedCommits pushes commits which were created without pushing them.
+func pushQueuedCommits(sys dotf.SysOpsProvider, cfg dotf.Config) error {
+	count, err := sys.UnpushedCommits(cfg.Repo, cfg.Git)
+
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	sys.Log(fmt.Sprintf("pushing %d queued commit(s)\n", count))
+
+	return sys.PushRepo(cfg.Repo, cfg.Git)
+}
@@ -2,7 +2,9 @@ package commands_test
 
 import (
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"bakku.dev/dotf"
 	"bakku.dev/dotf/commands"
@@ -18,7 +20,7 @@ func TestPush_ShouldFailIfNoHomeVarExists(t *testing.T) {
 
 	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("")
 
-	err := commands.Push(m, "")
+	err := commands.Push(m, commands.PushOptions{})
 
 	if err == nil {
 		t.Fatalf("Expected err to not be nil")
@@ -36,7 +38,7 @@ func TestPush_ShouldAbortIfDotfileDoesNotExist(t *testing.T) {
 	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
 	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(false)
 
-	err := commands.Push(m, "")
+	err := commands.Push(m, commands.PushOptions{})
 
 	if err == nil {
 		t.Fatalf("Expected err not to be nil")
@@ -55,7 +57,7 @@ func TestPush_ShouldFailIfConfigCannotBeRead(t *testing.T) {
 	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
 	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return(nil, errors.New("error"))
 
-	err := commands.Push(m, "")
+	err := commands.Push(m, commands.PushOptions{})
 
 	if err == nil {
 		t.Fatalf("Expected err not to be nil")
@@ -75,7 +77,7 @@ func TestPush_ShouldFailIfConfigCannotBeDeserialized(t *testing.T) {
 	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
 	m.EXPECT().DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).Return(errors.New("error"))
 
-	err := commands.Push(m, "")
+	err := commands.Push(m, commands.PushOptions{})
 
 	if err == nil {
 		t.Fatalf("Expected err not to be nil")
@@ -89,10 +91,10 @@ func TestPush_ShouldFailIfCopyFails(t *testing.T) {
 	m := mocks.NewMockSysOpsProvider(ctrl)
 
 	cfg := dotf.Config{
-		"/home/repo",
-		false,
-		[]dotf.TrackedFile{
-			{"/.vimrc", "/home/.vimrc"},
+		Repo:          "/home/repo",
+		CreateBackups: false,
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
 		},
 	}
 
@@ -105,11 +107,50 @@ func TestPush_ShouldFailIfCopyFails(t *testing.T) {
 		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
 		SetArg(1, cfg).
 		Return(nil)
+	m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(0, nil)
 	m.EXPECT().GetPathSep().Return("/")
 	m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc")
 	m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(errors.New("error"))
 
-	err := commands.Push(m, "")
+	err := commands.Push(m, commands.PushOptions{})
+
+	if err == nil {
+		t.Fatalf("Expected err not to be nil")
+	}
+}
+
+func TestPush_ShouldFailIfCommitFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo:          "/home/repo",
+		CreateBackups: false,
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(0, nil)
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc")
+	m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(nil)
+	m.EXPECT().RepoChanges("/home/repo", []string{"/.vimrc"}).Return(dotf.FileChanges{Modified: []string{".vimrc"}}, nil)
+	expectSecretScan(ctrl, m)
+	m.EXPECT().CommitRepo("/home/repo", "Update .vimrc", []string{"/.vimrc"}, dotf.GitConfig{}).Return(errors.New("error"))
+
+	err := commands.Push(m, commands.PushOptions{Message: "Update .vimrc"})
 
 	if err == nil {
 		t.Fatalf("Expected err not to be nil")
@@ -123,10 +164,10 @@ func TestPush_ShouldFailIfPushFails(t *testing.T) {
 	m := mocks.NewMockSysOpsProvider(ctrl)
 
 	cfg := dotf.Config{
-		"/home/repo",
-		false,
-		[]dotf.TrackedFile{
-			{"/.vimrc", "/home/.vimrc"},
+		Repo:          "/home/repo",
+		CreateBackups: false,
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
 		},
 	}
 
@@ -139,12 +180,16 @@ func TestPush_ShouldFailIfPushFails(t *testing.T) {
 		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
 		SetArg(1, cfg).
 		Return(nil)
+	m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(0, nil)
 	m.EXPECT().GetPathSep().Return("/")
 	m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc")
 	m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(nil)
-	m.EXPECT().CommitRepo("/home/repo", "Update .vimrc").Return(errors.New("error"))
+	m.EXPECT().RepoChanges("/home/repo", []string{"/.vimrc"}).Return(dotf.FileChanges{Modified: []string{".vimrc"}}, nil)
+	expectSecretScan(ctrl, m)
+	m.EXPECT().CommitRepo("/home/repo", "Update .vimrc", []string{"/.vimrc"}, dotf.GitConfig{}).Return(nil)
+	m.EXPECT().PushRepo("/home/repo", dotf.GitConfig{}).Return(errors.New("error"))
 
-	err := commands.Push(m, "Update .vimrc")
+	err := commands.Push(m, commands.PushOptions{Message: "Update .vimrc"})
 
 	if err == nil {
 		t.Fatalf("Expected err not to be nil")
@@ -158,10 +203,10 @@ func TestPush_ShouldWorkSuccessfullyWithoutErrors(t *testing.T) {
 	m := mocks.NewMockSysOpsProvider(ctrl)
 
 	cfg := dotf.Config{
-		"/home/repo",
-		false,
-		[]dotf.TrackedFile{
-			{"/.vimrc", "/home/.vimrc"},
+		Repo:          "/home/repo",
+		CreateBackups: false,
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
 		},
 	}
 
@@ -174,14 +219,511 @@ func TestPush_ShouldWorkSuccessfullyWithoutErrors(t *testing.T) {
 		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
 		SetArg(1, cfg).
 		Return(nil)
+	m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(0, nil)
 	m.EXPECT().GetPathSep().Return("/")
 	m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc")
 	m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(nil)
-	m.EXPECT().CommitRepo("/home/repo", "Update .vimrc").Return(nil)
+	m.EXPECT().RepoChanges("/home/repo", []string{"/.vimrc"}).Return(dotf.FileChanges{Modified: []string{".vimrc"}}, nil)
+	expectSecretScan(ctrl, m)
+	m.EXPECT().CommitRepo("/home/repo", "Update .vimrc", []string{"/.vimrc"}, dotf.GitConfig{}).Return(nil)
+	m.EXPECT().PushRepo("/home/repo", dotf.GitConfig{}).Return(nil)
 
-	err := commands.Push(m, "Update .vimrc")
+	err := commands.Push(m, commands.PushOptions{Message: "Update .vimrc"})
 
 	if err != nil {
 		t.Fatalf("Expected err not to be nil")
 	}
 }
+
+func TestPush_ShouldSucceedIfNothingChanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo:          "/home/repo",
+		CreateBackups: false,
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(0, nil)
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc")
+	m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(nil)
+	m.EXPECT().RepoChanges("/home/repo", []string{"/.vimrc"}).Return(dotf.FileChanges{}, nil)
+	m.EXPECT().Log("nothing to push\n")
+
+	err := commands.Push(m, commands.PushOptions{Message: "Update .vimrc"})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil")
+	}
+}
+
+func TestPush_ShouldGenerateCommitMessageIfNoneIsGiven(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+			{PathInRepo: "/.bashrc", PathOnSystem: "/home/.bashrc"},
+		},
+	}
+
+	changes := dotf.FileChanges{Added: []string{".bashrc"}, Modified: []string{".vimrc"}}
+	now := time.Date(2020, 11, 1, 12, 30, 0, 0, time.UTC)
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(0, nil)
+	m.EXPECT().GetPathSep().Return("/").Times(2)
+	m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc")
+	m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(nil)
+	m.EXPECT().CleanPath("/home/repo//.bashrc").Return("/home/repo/.bashrc")
+	m.EXPECT().CopyFile("/home/.bashrc", "/home/repo/.bashrc").Return(nil)
+	m.EXPECT().RepoChanges("/home/repo", []string{"/.vimrc", "/.bashrc"}).Return(changes, nil)
+	expectSecretScan(ctrl, m)
+	m.EXPECT().GetHostname().Return("laptop", nil)
+	m.EXPECT().Now().Return(now)
+	m.EXPECT().CommitRepo(
+		"/home/repo",
+		"Update dotfiles from laptop at 2020-11-01 12:30:00\n\nadded: .bashrc\nmodified: .vimrc",
+		[]string{"/.vimrc", "/.bashrc"},
+		dotf.GitConfig{},
+	).Return(nil)
+	m.EXPECT().PushRepo("/home/repo", dotf.GitConfig{}).Return(nil)
+
+	err := commands.Push(m, commands.PushOptions{})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestPush_ShouldUseCommitTemplateOfConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo:           "/home/repo",
+		CommitTemplate: "{{.Hostname}}: {{range .Modified}}{{.}} {{end}}",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	changes := dotf.FileChanges{Modified: []string{".vimrc"}}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(0, nil)
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc")
+	m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(nil)
+	m.EXPECT().RepoChanges("/home/repo", []string{"/.vimrc"}).Return(changes, nil)
+	expectSecretScan(ctrl, m)
+	m.EXPECT().GetHostname().Return("laptop", nil)
+	m.EXPECT().Now().Return(time.Now())
+	m.EXPECT().CommitRepo("/home/repo", "laptop: .vimrc", []string{"/.vimrc"}, dotf.GitConfig{}).Return(nil)
+	m.EXPECT().PushRepo("/home/repo", dotf.GitConfig{}).Return(nil)
+
+	err := commands.Push(m, commands.PushOptions{})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestPush_ShouldNotGenerateCommitMessageIfNothingChanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(0, nil)
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc")
+	m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(nil)
+	m.EXPECT().RepoChanges("/home/repo", []string{"/.vimrc"}).Return(dotf.FileChanges{}, nil)
+	m.EXPECT().Log("nothing to push\n")
+
+	err := commands.Push(m, commands.PushOptions{})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestPush_ShouldPushQueuedCommitsFirst(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+
+	m.EXPECT().RepoChanges("/home/repo", []string{"/.vimrc"}).Return(dotf.FileChanges{Modified: []string{".vimrc"}}, nil)
+	expectSecretScan(ctrl, m)
+
+	gomock.InOrder(
+		m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(2, nil),
+		m.EXPECT().Log("pushing 2 queued commit(s)\n"),
+		m.EXPECT().PushRepo("/home/repo", dotf.GitConfig{}).Return(nil),
+		m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(nil),
+		m.EXPECT().CommitRepo("/home/repo", "Update .vimrc", []string{"/.vimrc"}, dotf.GitConfig{}).Return(nil),
+		m.EXPECT().PushRepo("/home/repo", dotf.GitConfig{}).Return(nil),
+	)
+
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc")
+
+	err := commands.Push(m, commands.PushOptions{Message: "Update .vimrc"})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestPush_ShouldOnlyCommitWithNoPush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc")
+	m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(nil)
+	m.EXPECT().RepoChanges("/home/repo", []string{"/.vimrc"}).Return(dotf.FileChanges{Modified: []string{".vimrc"}}, nil)
+	expectSecretScan(ctrl, m)
+	m.EXPECT().CommitRepo("/home/repo", "Update .vimrc", []string{"/.vimrc"}, dotf.GitConfig{}).Return(nil)
+	m.EXPECT().Log("committed changes locally, they will be pushed with the next push\n")
+
+	err := commands.Push(m, commands.PushOptions{Message: "Update .vimrc", NoPush: true})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestPush_ShouldPushConfirmedChangesAfterShowingDiff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+	committed := mocks.NewMockRepoReader(ctrl)
+	staged := mocks.NewMockRepoReader(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+			{PathInRepo: "/.bashrc", PathOnSystem: "/home/.bashrc"},
+		},
+	}
+
+	expectedDiff := "" +
+		"--- /dev/null\n" +
+		"+++ b/.bashrc\n" +
+		"@@ -0,0 +1,1 @@\n" +
+		"+PS1=$\n" +
+		"--- a/.vimrc\n" +
+		"+++ b/.vimrc\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		" syntax on\n" +
+		"-set number\n" +
+		"+set relativenumber\n"
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/").Times(5)
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().ListUnpushedCommits("/home/repo", dotf.GitConfig{}).Return(nil, nil)
+	m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc").Times(2)
+	m.EXPECT().CleanPath("/home/repo//.bashrc").Return("/home/repo/.bashrc").Times(2)
+	m.EXPECT().PathExists("/home/repo/.vimrc").Return(true)
+	m.EXPECT().ReadFile("/home/repo/.vimrc").Return([]byte("syntax on\nset number\n"), nil)
+	m.EXPECT().PathExists("/home/repo/.bashrc").Return(false)
+	m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(nil)
+	m.EXPECT().CopyFile("/home/.bashrc", "/home/repo/.bashrc").Return(nil)
+	m.EXPECT().
+		RepoChanges("/home/repo", []string{"/.vimrc", "/.bashrc"}).
+		Return(dotf.FileChanges{Added: []string{".bashrc"}, Modified: []string{".vimrc"}}, nil).
+		Times(2)
+	m.EXPECT().OpenRepoReader("/home/repo", "HEAD").Return(committed, nil)
+	m.EXPECT().OpenRepoReader("/home/repo", "").Return(staged, nil).Times(2)
+	committed.EXPECT().ReadFile(".bashrc").Return(nil, dotf.ErrNotInRepo)
+	staged.EXPECT().ReadFile(".bashrc").Return([]byte("PS1=$\n"), nil).Times(2)
+	committed.EXPECT().ReadFile(".vimrc").Return([]byte("syntax on\nset number\n"), nil)
+	staged.EXPECT().ReadFile(".vimrc").Return([]byte("syntax on\nset relativenumber\n"), nil).Times(2)
+
+	gomock.InOrder(
+		m.EXPECT().Log("changes to push:\n  added    .bashrc (+1 -0)\n  modified .vimrc (+1 -1)\n"),
+		m.EXPECT().Log("push these changes? [y]es, [n]o, show [d]iff: "),
+		m.EXPECT().ReadLine().Return("d", nil),
+		m.EXPECT().Log(expectedDiff),
+		m.EXPECT().Log("push these changes? [y]es, [n]o, show [d]iff: "),
+		m.EXPECT().ReadLine().Return("y", nil),
+		m.EXPECT().CommitRepo("/home/repo", "Update", []string{"/.vimrc", "/.bashrc"}, dotf.GitConfig{}).Return(nil),
+		m.EXPECT().PushRepo("/home/repo", dotf.GitConfig{}).Return(nil),
+	)
+
+	err := commands.Push(m, commands.PushOptions{Message: "Update", Confirm: true})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestPush_ShouldRestoreRepoFilesIfNotConfirmed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+	reader := mocks.NewMockRepoReader(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/").Times(3)
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().ListUnpushedCommits("/home/repo", dotf.GitConfig{}).Return(nil, nil)
+	m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc").Times(2)
+	// the repo copy contains an uncommitted edit which must survive
+	m.EXPECT().PathExists("/home/repo/.vimrc").Return(true)
+	m.EXPECT().ReadFile("/home/repo/.vimrc").Return([]byte("set number\nset mouse=a\n"), nil)
+	m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(nil)
+	m.EXPECT().
+		RepoChanges("/home/repo", []string{"/.vimrc"}).
+		Return(dotf.FileChanges{Modified: []string{".vimrc"}}, nil)
+	m.EXPECT().OpenRepoReader("/home/repo", "HEAD").Return(reader, nil)
+	m.EXPECT().OpenRepoReader("/home/repo", "").Return(reader, nil)
+	reader.EXPECT().ReadFile(".vimrc").Return([]byte("set number\n"), nil).Times(2)
+	m.EXPECT().Log(gomock.Any()).Times(2)
+	m.EXPECT().ReadLine().Return("n", nil)
+	m.EXPECT().WriteFile("/home/repo/.vimrc", []byte("set number\nset mouse=a\n")).Return(nil)
+	m.EXPECT().Log("push cancelled, the repo is unchanged\n")
+
+	err := commands.Push(m, commands.PushOptions{Confirm: true})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestPush_ShouldRestoreRepoFilesIfConfirmationFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+	reader := mocks.NewMockRepoReader(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/").Times(3)
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().ListUnpushedCommits("/home/repo", dotf.GitConfig{}).Return(nil, nil)
+	m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc").Times(2)
+	// the repo copy contains an uncommitted edit which must survive
+	m.EXPECT().PathExists("/home/repo/.vimrc").Return(true)
+	m.EXPECT().ReadFile("/home/repo/.vimrc").Return([]byte("set number\nset mouse=a\n"), nil)
+	m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(nil)
+	m.EXPECT().
+		RepoChanges("/home/repo", []string{"/.vimrc"}).
+		Return(dotf.FileChanges{Modified: []string{".vimrc"}}, nil)
+	m.EXPECT().OpenRepoReader("/home/repo", "HEAD").Return(reader, nil)
+	m.EXPECT().OpenRepoReader("/home/repo", "").Return(reader, nil)
+	reader.EXPECT().ReadFile(".vimrc").Return([]byte("set number\n"), nil).Times(2)
+	m.EXPECT().Log(gomock.Any()).Times(2)
+	// stdin is not interactive
+	m.EXPECT().ReadLine().Return("", errors.New("error while reading: EOF"))
+	m.EXPECT().WriteFile("/home/repo/.vimrc", []byte("set number\nset mouse=a\n")).Return(nil)
+
+	err := commands.Push(m, commands.PushOptions{Confirm: true})
+
+	if err == nil || !strings.Contains(err.Error(), "EOF") {
+		t.Fatalf("Expected err about reading the answer, got %v", err)
+	}
+}
+
+// expectSecretScan sets up reading the changed files from the repo worktree
+// for the secret scan. The files contain no secrets.
+func expectSecretScan(ctrl *gomock.Controller, m *mocks.MockSysOpsProvider) {
+	reader := mocks.NewMockRepoReader(ctrl)
+
+	m.EXPECT().OpenRepoReader("/home/repo", "").Return(reader, nil)
+	reader.EXPECT().ReadFile(gomock.Any()).Return([]byte("set number\n"), nil).AnyTimes()
+}
+
+func TestPush_ShouldOnlyPushQueuedCommitsAfterConfirmation(t *testing.T) {
+	for _, answer := range []string{"y", "n"} {
+		t.Run(answer, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mocks.NewMockSysOpsProvider(ctrl)
+			reader := mocks.NewMockRepoReader(ctrl)
+
+			cfg := dotf.Config{
+				Repo: "/home/repo",
+				TrackedFiles: []dotf.TrackedFile{
+					{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+				},
+			}
+
+			queued := []dotf.Commit{
+				{Hash: "8f3a2c1d9e0b7a6f5c4d3e2f1a0b9c8d7e6f5a4b", Message: "Enable line numbers\n\nThey help.\n"},
+			}
+
+			m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+			m.EXPECT().GetPathSep().Return("/").Times(3)
+			m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+			m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+			m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+			m.EXPECT().
+				DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+				SetArg(1, cfg).
+				Return(nil)
+			m.EXPECT().ListUnpushedCommits("/home/repo", dotf.GitConfig{}).Return(queued, nil)
+			m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc").Times(2)
+			m.EXPECT().PathExists("/home/repo/.vimrc").Return(true)
+			m.EXPECT().ReadFile("/home/repo/.vimrc").Return([]byte("set number\n"), nil)
+			m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(nil)
+			m.EXPECT().OpenRepoReader("/home/repo", "HEAD").Return(reader, nil)
+			m.EXPECT().OpenRepoReader("/home/repo", "").Return(reader, nil)
+
+			calls := []*gomock.Call{
+				m.EXPECT().RepoChanges("/home/repo", []string{"/.vimrc"}).Return(dotf.FileChanges{}, nil),
+				m.EXPECT().Log("queued commits to push:\n  8f3a2c1 Enable line numbers\n"),
+				m.EXPECT().Log("push these changes? [y]es, [n]o, show [d]iff: "),
+				m.EXPECT().ReadLine().Return(answer, nil),
+			}
+
+			if answer == "y" {
+				calls = append(calls,
+					m.EXPECT().RepoChanges("/home/repo", []string{"/.vimrc"}).Return(dotf.FileChanges{}, nil),
+					m.EXPECT().PushRepo("/home/repo", dotf.GitConfig{}).Return(nil),
+				)
+			} else {
+				calls = append(calls,
+					m.EXPECT().WriteFile("/home/repo/.vimrc", []byte("set number\n")).Return(nil),
+					m.EXPECT().Log("push cancelled, the repo is unchanged\n"),
+				)
+			}
+
+			gomock.InOrder(calls...)
+
+			err := commands.Push(m, commands.PushOptions{Confirm: true})
+
+			if err != nil {
+				t.Fatalf("Expected err to be nil, got %v", err)
+			}
+		})
+	}
+}
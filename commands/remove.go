@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 
 	"bakku.dev/dotf"
@@ -28,30 +29,20 @@ func removeTrackedFile(sys dotf.SysOpsProvider, dotfilePath, systemFilePath stri
 		return fmt.Errorf("rm: %v", err)
 	}
 
-	cfg, err := readConfig(sys, dotfilePath)
+	err = updateConfig(sys, dotfilePath, func(cfg *dotf.Config) error {
+		for i, trackedFile := range cfg.TrackedFiles {
+			if trackedFile.PathOnSystem == absoluteSystemFilePath {
+				cfg.TrackedFiles = append(cfg.TrackedFiles[:i], cfg.TrackedFiles[i+1:]...)
 
-	if err != nil {
-		return fmt.Errorf("rm: %v", err)
-	}
-
-	var trackedFileExists bool
-
-	for i, trackedFile := range cfg.TrackedFiles {
-		if trackedFile.PathOnSystem == absoluteSystemFilePath {
-			trackedFileExists = true
-			cfg.TrackedFiles = append(cfg.TrackedFiles[:i], cfg.TrackedFiles[i+1:]...)
-			break
+				return nil
+			}
 		}
-	}
-
-	if !trackedFileExists {
-		return fmt.Errorf("rm: given file is not a tracked file")
-	}
 
-	err = writeConfig(sys, dotfilePath, cfg)
+		return errors.New("given file is not a tracked file")
+	})
 
 	if err != nil {
-		return fmt.Errorf("add: %v", err)
+		return fmt.Errorf("rm: %v", err)
 	}
 
 	return nil
@@ -117,7 +117,7 @@ func TestRemove_ShouldFailIfFileIsNotTracked(t *testing.T) {
 	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
 	m.EXPECT().
 		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
-		SetArg(1, dotf.Config{"", false, []dotf.TrackedFile{}}).
+		SetArg(1, dotf.Config{Repo: "", CreateBackups: false, TrackedFiles: []dotf.TrackedFile{}}).
 		Return(nil)
 
 	err := commands.Remove(m, "/home//.vimrc")
@@ -141,10 +141,10 @@ func TestRemove_ShouldFailIfConfigCannotBeSerialized(t *testing.T) {
 	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
 	m.EXPECT().
 		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
-		SetArg(1, dotf.Config{"", false, []dotf.TrackedFile{{".vimrc", "/home/.vimrc"}}}).
+		SetArg(1, dotf.Config{Repo: "", CreateBackups: false, TrackedFiles: []dotf.TrackedFile{{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"}}}).
 		Return(nil)
 
-	m.EXPECT().SerializeConfig(gomock.Eq(dotf.Config{"", false, []dotf.TrackedFile{}})).Return(nil, errors.New("error"))
+	m.EXPECT().SerializeConfig(gomock.Eq(dotf.Config{Repo: "", CreateBackups: false, TrackedFiles: []dotf.TrackedFile{}})).Return(nil, errors.New("error"))
 
 	err := commands.Remove(m, "/home//.vimrc")
 
@@ -167,11 +167,11 @@ func TestRemove_ShouldFailIfConfigCannotBeWritten(t *testing.T) {
 	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
 	m.EXPECT().
 		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
-		SetArg(1, dotf.Config{"", false, []dotf.TrackedFile{{".vimrc", "/home/.vimrc"}}}).
+		SetArg(1, dotf.Config{Repo: "", CreateBackups: false, TrackedFiles: []dotf.TrackedFile{{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"}}}).
 		Return(nil)
 
-	m.EXPECT().SerializeConfig(gomock.Eq(dotf.Config{"", false, []dotf.TrackedFile{}})).Return([]byte("ABC"), nil)
-	m.EXPECT().WriteFile("/home/.dotf", []byte("ABC")).Return(errors.New("error"))
+	m.EXPECT().SerializeConfig(gomock.Eq(dotf.Config{Repo: "", CreateBackups: false, TrackedFiles: []dotf.TrackedFile{}})).Return([]byte("ABC"), nil)
+	m.EXPECT().ReplaceFile("/home/.dotf", []byte("ABC"), []byte("ABC")).Return(errors.New("error"))
 
 	err := commands.Remove(m, "/home//.vimrc")
 
@@ -194,11 +194,11 @@ func TestRemove_ShouldWorkSuccessfullyIfNoErrorsOccur(t *testing.T) {
 	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
 	m.EXPECT().
 		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
-		SetArg(1, dotf.Config{"", false, []dotf.TrackedFile{{".vimrc", "/home/.vimrc"}}}).
+		SetArg(1, dotf.Config{Repo: "", CreateBackups: false, TrackedFiles: []dotf.TrackedFile{{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"}}}).
 		Return(nil)
 
-	m.EXPECT().SerializeConfig(gomock.Eq(dotf.Config{"", false, []dotf.TrackedFile{}})).Return([]byte("ABC"), nil)
-	m.EXPECT().WriteFile("/home/.dotf", []byte("ABC")).Return(nil)
+	m.EXPECT().SerializeConfig(gomock.Eq(dotf.Config{Repo: "", CreateBackups: false, TrackedFiles: []dotf.TrackedFile{}})).Return([]byte("ABC"), nil)
+	m.EXPECT().ReplaceFile("/home/.dotf", []byte("ABC"), []byte("ABC")).Return(nil)
 
 	err := commands.Remove(m, "/home//.vimrc")
 
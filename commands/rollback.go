@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"bakku.dev/dotf"
+)
+
+// RollbackOptions configures the rollback command.
+type RollbackOptions struct {
+	// Commit also writes the restored content into the repo and commits it.
+	// The commit is pushed with the next push.
+	Commit bool
+}
+
+// Rollback restores the content a tracked file had in the given revision onto
+// the system. The current content is backed up first.
+func Rollback(sys dotf.SysOpsProvider, systemFilePath, rev string, opts RollbackOptions) error {
+	dotfilePath, err := getDotfConfigPath(sys)
+
+	if err != nil {
+		return fmt.Errorf("rollback: %v", err)
+	}
+
+	return rollbackTrackedFile(sys, dotfilePath, systemFilePath, rev, opts)
+}
+
+func rollbackTrackedFile(sys dotf.SysOpsProvider, dotfilePath, systemFilePath, rev string, opts RollbackOptions) error {
+	cfg, err := readConfig(sys, dotfilePath)
+
+	if err != nil {
+		return fmt.Errorf("rollback: %v", err)
+	}
+
+	tf, err := findTrackedFile(sys, cfg, systemFilePath)
+
+	if err != nil {
+		return fmt.Errorf("rollback: %v", err)
+	}
+
+	revision, err := sys.ResolveRevision(cfg.Repo, rev)
+
+	if err != nil {
+		return fmt.Errorf("rollback: %v", err)
+	}
+
+	reader, err := sys.OpenRepoReader(cfg.Repo, revision)
+
+	if err != nil {
+		return fmt.Errorf("rollback: %v", err)
+	}
+
+	content, err := reader.ReadFile(tf.PathInRepo)
+
+	if errors.Is(err, dotf.ErrNotInRepo) {
+		return fmt.Errorf("rollback: %s does not exist in %s", tf.PathInRepo, rev)
+	}
+
+	if err != nil {
+		return fmt.Errorf("rollback: %v", err)
+	}
+
+	err = sys.CopyFile(tf.PathOnSystem, tf.PathOnSystem+".bk")
+
+	if err != nil {
+		return fmt.Errorf("rollback: %v", err)
+	}
+
+	err = sys.WriteFile(tf.PathOnSystem, content)
+
+	if err != nil {
+		return fmt.Errorf("rollback: %v", err)
+	}
+
+	sys.Log(fmt.Sprintf("rolled back %s to %s, the previous version is in %s.bk\n", tf.PathOnSystem, shortHash(revision), tf.PathOnSystem))
+
+	if !opts.Commit {
+		return nil
+	}
+
+	err = sys.WriteFile(sys.CleanPath(cfg.Repo+sys.GetPathSep()+tf.PathInRepo), content)
+
+	if err != nil {
+		return fmt.Errorf("rollback: %v", err)
+	}
+
+	message := fmt.Sprintf("Roll back %s to %s", tf.PathInRepo, shortHash(revision))
+	changes, err := commitToRepo(sys, cfg, []string{tf.PathInRepo}, message)
+
+	if err != nil {
+		return fmt.Errorf("rollback: %v", err)
+	}
+
+	if !changes.Empty() {
+		sys.Log("committed the rollback, it will be pushed with the next push\n")
+	}
+
+	return nil
+}
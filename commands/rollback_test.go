@@ -0,0 +1,148 @@
+package commands_test
+
+import (
+	"testing"
+
+	"bakku.dev/dotf"
+	"bakku.dev/dotf/commands"
+	"bakku.dev/dotf/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRollback_ShouldFailIfNoHomeVarExists(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("")
+
+	err := commands.Rollback(m, "/home/.vimrc", "HEAD~1", commands.RollbackOptions{})
+
+	if err == nil {
+		t.Fatalf("Expected err to not be nil")
+	}
+}
+
+func TestRollback_ShouldFailIfFileIsNotInRevision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+	reader := mocks.NewMockRepoReader(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().ExpandPath("/home/.vimrc").Return("/home/.vimrc", nil)
+	m.EXPECT().ResolveRevision("/home/repo", "v1").Return("8f3a2c1d9e0b", nil)
+	m.EXPECT().OpenRepoReader("/home/repo", "8f3a2c1d9e0b").Return(reader, nil)
+	reader.EXPECT().ReadFile(".vimrc").Return(nil, dotf.ErrNotInRepo)
+
+	err := commands.Rollback(m, "/home/.vimrc", "v1", commands.RollbackOptions{})
+
+	if err == nil {
+		t.Fatalf("Expected err not to be nil")
+	}
+}
+
+func TestRollback_ShouldRestoreFileWithBackup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+	reader := mocks.NewMockRepoReader(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().ExpandPath("/home/.vimrc").Return("/home/.vimrc", nil)
+	m.EXPECT().ResolveRevision("/home/repo", "HEAD~1").Return("8f3a2c1d9e0b", nil)
+	m.EXPECT().OpenRepoReader("/home/repo", "8f3a2c1d9e0b").Return(reader, nil)
+	reader.EXPECT().ReadFile(".vimrc").Return([]byte("set number"), nil)
+
+	gomock.InOrder(
+		m.EXPECT().CopyFile("/home/.vimrc", "/home/.vimrc.bk").Return(nil),
+		m.EXPECT().WriteFile("/home/.vimrc", []byte("set number")).Return(nil),
+		m.EXPECT().Log("rolled back /home/.vimrc to 8f3a2c1, the previous version is in /home/.vimrc.bk\n"),
+	)
+
+	err := commands.Rollback(m, "/home/.vimrc", "HEAD~1", commands.RollbackOptions{})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestRollback_ShouldCommitRestoredFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+	reader := mocks.NewMockRepoReader(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/").Times(2)
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().ExpandPath("/home/.vimrc").Return("/home/.vimrc", nil)
+	m.EXPECT().ResolveRevision("/home/repo", "HEAD~1").Return("8f3a2c1d9e0b", nil)
+	m.EXPECT().OpenRepoReader("/home/repo", "8f3a2c1d9e0b").Return(reader, nil)
+	reader.EXPECT().ReadFile(".vimrc").Return([]byte("set number"), nil).Times(2)
+	m.EXPECT().CleanPath("/home/repo/.vimrc").Return("/home/repo/.vimrc")
+
+	gomock.InOrder(
+		m.EXPECT().CopyFile("/home/.vimrc", "/home/.vimrc.bk").Return(nil),
+		m.EXPECT().WriteFile("/home/.vimrc", []byte("set number")).Return(nil),
+		m.EXPECT().Log(gomock.Any()),
+		m.EXPECT().WriteFile("/home/repo/.vimrc", []byte("set number")).Return(nil),
+		m.EXPECT().RepoChanges("/home/repo", []string{".vimrc"}).Return(dotf.FileChanges{Modified: []string{".vimrc"}}, nil),
+		m.EXPECT().OpenRepoReader("/home/repo", "").Return(reader, nil),
+		m.EXPECT().CommitRepo("/home/repo", "Roll back .vimrc to 8f3a2c1", []string{".vimrc"}, dotf.GitConfig{}).Return(nil),
+		m.EXPECT().Log("committed the rollback, it will be pushed with the next push\n"),
+	)
+
+	err := commands.Rollback(m, "/home/.vimrc", "HEAD~1", commands.RollbackOptions{Commit: true})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
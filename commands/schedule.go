@@ -0,0 +1,388 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"bakku.dev/dotf"
+)
+
+const (
+	scheduleUnitName   = "dotf-sync"
+	scheduleCronMarker = "# dotf sync"
+	scheduleLogName    = ".dotf-sync.log"
+)
+
+const scheduleServiceTemplate = `[Unit]
+Description=Sync dotfiles with dotf
+
+[Service]
+Type=oneshot
+ExecStart=/bin/sh -c %s
+`
+
+const scheduleTimerTemplate = `[Unit]
+Description=Sync dotfiles with dotf every %s
+
+[Timer]
+OnBootSec=5min
+OnUnitActiveSec=%ds
+
+[Install]
+WantedBy=timers.target
+`
+
+// ScheduleOptions configures the schedule install command.
+type ScheduleOptions struct {
+	// Every is the interval in which dotf sync runs.
+	Every time.Duration
+}
+
+// InstallSchedule runs dotf sync periodically in the background. A systemd
+// user timer is used if systemd is available, a crontab entry otherwise. The
+// output of every run is appended to $HOME/.dotf-sync.log.
+func InstallSchedule(sys dotf.SysOpsProvider, opts ScheduleOptions) error {
+	_, err := getDotfConfigPath(sys)
+
+	if err != nil {
+		return fmt.Errorf("schedule install: %v", err)
+	}
+
+	err = installSchedule(sys, opts)
+
+	if err != nil {
+		return fmt.Errorf("schedule install: %v", err)
+	}
+
+	return nil
+}
+
+func installSchedule(sys dotf.SysOpsProvider, opts ScheduleOptions) error {
+	if opts.Every < time.Minute {
+		return fmt.Errorf("the interval must be at least one minute, got %s", opts.Every)
+	}
+
+	executable, err := sys.Executable()
+
+	if err != nil {
+		return fmt.Errorf("could not determine path of dotf: %v", err)
+	}
+
+	logPath := scheduleLogPath(sys)
+	command := fmt.Sprintf("exec %s sync < /dev/null >> %s 2>&1", shellQuote(executable), shellQuote(logPath))
+
+	if systemdAvailable(sys) {
+		return installSystemdTimer(sys, opts.Every, command, logPath)
+	}
+
+	return installCronEntry(sys, opts.Every, command, logPath)
+}
+
+// ScheduleStatus shows how dotf sync is scheduled.
+func ScheduleStatus(sys dotf.SysOpsProvider) error {
+	_, err := getDotfConfigPath(sys)
+
+	if err != nil {
+		return fmt.Errorf("schedule status: %v", err)
+	}
+
+	err = showSchedule(sys)
+
+	if err != nil {
+		return fmt.Errorf("schedule status: %v", err)
+	}
+
+	return nil
+}
+
+func showSchedule(sys dotf.SysOpsProvider) error {
+	service, timer := scheduleUnitPaths(sys)
+
+	if sys.PathExists(timer) {
+		output, err := runScheduleCommand(sys, "systemctl --user list-timers --all --no-pager "+scheduleUnitName+".timer", nil)
+
+		if err != nil {
+			return err
+		}
+
+		sys.Log(fmt.Sprintf("scheduled by systemd timer %s (%s)\n", timer, service))
+		sys.Log(output)
+		sys.Log(fmt.Sprintf("log: %s\n", scheduleLogPath(sys)))
+
+		return nil
+	}
+
+	lines, err := readCrontab(sys)
+
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		if strings.HasSuffix(line, scheduleCronMarker) {
+			sys.Log(fmt.Sprintf("scheduled by crontab entry: %s\n", line))
+			sys.Log(fmt.Sprintf("log: %s\n", scheduleLogPath(sys)))
+
+			return nil
+		}
+	}
+
+	sys.Log("dotf sync is not scheduled\n")
+
+	return nil
+}
+
+// RemoveSchedule stops running dotf sync periodically.
+func RemoveSchedule(sys dotf.SysOpsProvider) error {
+	_, err := getDotfConfigPath(sys)
+
+	if err != nil {
+		return fmt.Errorf("schedule remove: %v", err)
+	}
+
+	err = removeSchedule(sys)
+
+	if err != nil {
+		return fmt.Errorf("schedule remove: %v", err)
+	}
+
+	return nil
+}
+
+func removeSchedule(sys dotf.SysOpsProvider) error {
+	service, timer := scheduleUnitPaths(sys)
+
+	if sys.PathExists(timer) {
+		_, err := runScheduleCommand(sys, "systemctl --user disable --now "+scheduleUnitName+".timer", nil)
+
+		if err != nil {
+			return err
+		}
+
+		for _, path := range []string{timer, service} {
+			err = sys.RemoveFile(path)
+
+			if err != nil {
+				return fmt.Errorf("could not remove %s: %v", path, err)
+			}
+		}
+
+		_, err = runScheduleCommand(sys, "systemctl --user daemon-reload", nil)
+
+		if err != nil {
+			return err
+		}
+
+		sys.Log(fmt.Sprintf("removed systemd timer %s\n", timer))
+
+		return nil
+	}
+
+	lines, err := readCrontab(sys)
+
+	if err != nil {
+		return err
+	}
+
+	kept := withoutCronEntry(lines)
+
+	if len(kept) == len(lines) {
+		sys.Log("dotf sync is not scheduled\n")
+
+		return nil
+	}
+
+	err = writeCrontab(sys, kept)
+
+	if err != nil {
+		return err
+	}
+
+	sys.Log("removed crontab entry\n")
+
+	return nil
+}
+
+// installSystemdTimer writes a service and a timer unit into the systemd user
+// directory and enables the timer.
+func installSystemdTimer(sys dotf.SysOpsProvider, every time.Duration, command, logPath string) error {
+	service, timer := scheduleUnitPaths(sys)
+
+	units := []struct {
+		path    string
+		content string
+	}{
+		{service, fmt.Sprintf(scheduleServiceTemplate, systemdQuote(command))},
+		{timer, fmt.Sprintf(scheduleTimerTemplate, every, int64(every/time.Second))},
+	}
+
+	for _, unit := range units {
+		err := sys.WriteFile(unit.path, []byte(unit.content))
+
+		if err != nil {
+			return fmt.Errorf("could not write %s: %v", unit.path, err)
+		}
+	}
+
+	for _, line := range []string{
+		"systemctl --user daemon-reload",
+		"systemctl --user enable --now " + scheduleUnitName + ".timer",
+	} {
+		_, err := runScheduleCommand(sys, line, nil)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	sys.Log(fmt.Sprintf("installed systemd timer %s, dotf sync runs every %s, log: %s\n", timer, every, logPath))
+
+	return nil
+}
+
+// installCronEntry replaces the dotf entry of the crontab of the user.
+func installCronEntry(sys dotf.SysOpsProvider, every time.Duration, command, logPath string) error {
+	schedule, err := cronSchedule(every)
+
+	if err != nil {
+		return err
+	}
+
+	lines, err := readCrontab(sys)
+
+	if err != nil {
+		return err
+	}
+
+	// cron turns an unescaped % of the command into a newline
+	lines = append(withoutCronEntry(lines), fmt.Sprintf("%s %s %s", schedule, strings.ReplaceAll(command, "%", `\%`), scheduleCronMarker))
+
+	err = writeCrontab(sys, lines)
+
+	if err != nil {
+		return err
+	}
+
+	sys.Log(fmt.Sprintf("systemd is not available, installed crontab entry, dotf sync runs every %s, log: %s\n", every, logPath))
+
+	return nil
+}
+
+// cronSchedule converts an interval into the schedule fields of a crontab
+// entry. Only intervals which divide an hour or a day evenly can be expressed.
+func cronSchedule(every time.Duration) (string, error) {
+	switch {
+	case every%time.Minute == 0 && every < time.Hour && time.Hour%every == 0:
+		return fmt.Sprintf("*/%d * * * *", every/time.Minute), nil
+	case every%time.Hour == 0 && every < 24*time.Hour && 24*time.Hour%every == 0:
+		return fmt.Sprintf("0 */%d * * *", every/time.Hour), nil
+	case every == 24*time.Hour:
+		return "0 0 * * *", nil
+	default:
+		return "", fmt.Errorf("crontab cannot run every %s, use an interval which divides an hour or a day", every)
+	}
+}
+
+// systemdAvailable reports whether a systemd user instance is running.
+func systemdAvailable(sys dotf.SysOpsProvider) bool {
+	_, exitCode, err := sys.RunCommand(dotf.Command{Line: "systemctl --user show-environment"})
+
+	return err == nil && exitCode == 0
+}
+
+// readCrontab returns the lines of the crontab of the user. A missing crontab
+// is empty.
+func readCrontab(sys dotf.SysOpsProvider) ([]string, error) {
+	output, exitCode, err := sys.RunCommand(dotf.Command{Line: "crontab -l"})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if exitCode != 0 {
+		return nil, nil
+	}
+
+	var lines []string
+
+	for _, line := range strings.Split(string(output), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
+
+func writeCrontab(sys dotf.SysOpsProvider, lines []string) error {
+	content := ""
+
+	if len(lines) > 0 {
+		content = strings.Join(lines, "\n") + "\n"
+	}
+
+	_, err := runScheduleCommand(sys, "crontab -", []byte(content))
+
+	return err
+}
+
+func withoutCronEntry(lines []string) []string {
+	var kept []string
+
+	for _, line := range lines {
+		if !strings.HasSuffix(line, scheduleCronMarker) {
+			kept = append(kept, line)
+		}
+	}
+
+	return kept
+}
+
+// runScheduleCommand runs a command and turns a non-zero exit code into an
+// error containing its output.
+func runScheduleCommand(sys dotf.SysOpsProvider, line string, stdin []byte) (string, error) {
+	output, exitCode, err := sys.RunCommand(dotf.Command{Line: line, Stdin: stdin})
+
+	if err != nil {
+		return "", err
+	}
+
+	if exitCode != 0 {
+		return "", fmt.Errorf("%s failed with exit code %d: %s", line, exitCode, strings.TrimSpace(string(output)))
+	}
+
+	return string(output), nil
+}
+
+// scheduleUnitPaths returns the paths of the systemd service and timer units.
+// They are stored in $XDG_CONFIG_HOME/systemd/user, or in
+// $HOME/.config/systemd/user if XDG_CONFIG_HOME is not set.
+func scheduleUnitPaths(sys dotf.SysOpsProvider) (string, string) {
+	sep := sys.GetPathSep()
+	configHome := sys.GetEnvVar("XDG_CONFIG_HOME")
+
+	if configHome == "" {
+		configHome = sys.GetEnvVar("HOME") + sep + ".config"
+	}
+
+	dir := configHome + sep + "systemd" + sep + "user" + sep
+
+	return sys.CleanPath(dir + scheduleUnitName + ".service"), sys.CleanPath(dir + scheduleUnitName + ".timer")
+}
+
+func scheduleLogPath(sys dotf.SysOpsProvider) string {
+	return sys.CleanPath(sys.GetEnvVar("HOME") + sys.GetPathSep() + scheduleLogName)
+}
+
+// systemdQuote quotes s as a single argument of a systemd Exec line. Within
+// double quotes systemd unescapes backslashes and quotes, expands $ as
+// environment variable and % as specifier, so all of them are escaped.
+func systemdQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", "$$", "%", "%%").Replace(s) + `"`
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
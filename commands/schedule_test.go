@@ -0,0 +1,207 @@
+package commands_test
+
+import (
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"bakku.dev/dotf"
+	"bakku.dev/dotf/commands"
+	"bakku.dev/dotf/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+// expectScheduleSetup sets up finding the dotf config and building paths
+// below /home.
+func expectScheduleSetup(m *mocks.MockSysOpsProvider, xdgConfigHome string) {
+	m.EXPECT().GetEnvVar("HOME").Return("/home/").AnyTimes()
+	m.EXPECT().GetEnvVar("XDG_CONFIG_HOME").Return(xdgConfigHome).AnyTimes()
+	m.EXPECT().GetPathSep().Return("/").AnyTimes()
+	m.EXPECT().CleanPath(gomock.Any()).DoAndReturn(path.Clean).AnyTimes()
+	m.EXPECT().PathExists("/home/.dotf").Return(true)
+}
+
+func TestInstallSchedule_ShouldWriteAndEnableSystemdUnits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	expectScheduleSetup(m, "/tmp/config")
+	m.EXPECT().Executable().Return("/usr/local/bin/dotf", nil)
+	m.EXPECT().RunCommand(dotf.Command{Line: "systemctl --user show-environment"}).Return(nil, 0, nil)
+
+	service := "[Unit]\n" +
+		"Description=Sync dotfiles with dotf\n" +
+		"\n" +
+		"[Service]\n" +
+		"Type=oneshot\n" +
+		"ExecStart=/bin/sh -c \"exec '/usr/local/bin/dotf' sync < /dev/null >> '/home/.dotf-sync.log' 2>&1\"\n"
+	timer := "[Unit]\n" +
+		"Description=Sync dotfiles with dotf every 1h30m0s\n" +
+		"\n" +
+		"[Timer]\n" +
+		"OnBootSec=5min\n" +
+		"OnUnitActiveSec=5400s\n" +
+		"\n" +
+		"[Install]\n" +
+		"WantedBy=timers.target\n"
+
+	gomock.InOrder(
+		m.EXPECT().WriteFile("/tmp/config/systemd/user/dotf-sync.service", []byte(service)).Return(nil),
+		m.EXPECT().WriteFile("/tmp/config/systemd/user/dotf-sync.timer", []byte(timer)).Return(nil),
+		m.EXPECT().RunCommand(dotf.Command{Line: "systemctl --user daemon-reload"}).Return(nil, 0, nil),
+		m.EXPECT().RunCommand(dotf.Command{Line: "systemctl --user enable --now dotf-sync.timer"}).Return(nil, 0, nil),
+		m.EXPECT().Log("installed systemd timer /tmp/config/systemd/user/dotf-sync.timer, dotf sync runs every 1h30m0s, log: /home/.dotf-sync.log\n"),
+	)
+
+	err := commands.InstallSchedule(m, commands.ScheduleOptions{Every: 90 * time.Minute})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestInstallSchedule_ShouldEscapeSystemdSpecialCharacters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	expectScheduleSetup(m, "/tmp/config")
+	m.EXPECT().Executable().Return(`/opt/"dotf" $v1\100%/dotf`, nil)
+	m.EXPECT().RunCommand(dotf.Command{Line: "systemctl --user show-environment"}).Return(nil, 0, nil)
+	m.EXPECT().
+		WriteFile("/tmp/config/systemd/user/dotf-sync.service", gomock.Any()).
+		DoAndReturn(func(_ string, content []byte) error {
+			expected := `ExecStart=/bin/sh -c "exec '/opt/\"dotf\" $$v1\\100%%/dotf' sync < /dev/null >> '/home/.dotf-sync.log' 2>&1"` + "\n"
+
+			if !strings.HasSuffix(string(content), expected) {
+				t.Errorf("Expected service to end with %q, got %q", expected, content)
+			}
+
+			return nil
+		})
+	m.EXPECT().WriteFile("/tmp/config/systemd/user/dotf-sync.timer", gomock.Any()).Return(nil)
+	m.EXPECT().RunCommand(gomock.Any()).Return(nil, 0, nil).Times(2)
+	m.EXPECT().Log(gomock.Any())
+
+	err := commands.InstallSchedule(m, commands.ScheduleOptions{Every: time.Hour})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestInstallSchedule_ShouldReplaceCrontabEntryWithoutSystemd(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	expectScheduleSetup(m, "")
+	m.EXPECT().Executable().Return("/usr/local/bin/dotf", nil)
+	m.EXPECT().RunCommand(dotf.Command{Line: "systemctl --user show-environment"}).Return(nil, 127, nil)
+	m.EXPECT().
+		RunCommand(dotf.Command{Line: "crontab -l"}).
+		Return([]byte("0 3 * * * backup.sh\n0 * * * * exec 'dotf' sync # dotf sync\n"), 0, nil)
+	m.EXPECT().
+		RunCommand(dotf.Command{
+			Line: "crontab -",
+			Stdin: []byte("0 3 * * * backup.sh\n" +
+				"*/15 * * * * exec '/usr/local/bin/dotf' sync < /dev/null >> '/home/.dotf-sync.log' 2>&1 # dotf sync\n"),
+		}).
+		Return(nil, 0, nil)
+	m.EXPECT().Log("systemd is not available, installed crontab entry, dotf sync runs every 15m0s, log: /home/.dotf-sync.log\n")
+
+	err := commands.InstallSchedule(m, commands.ScheduleOptions{Every: 15 * time.Minute})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestInstallSchedule_ShouldEscapePercentInCrontabEntry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	expectScheduleSetup(m, "")
+	m.EXPECT().Executable().Return("/opt/100%/dotf", nil)
+	m.EXPECT().RunCommand(dotf.Command{Line: "systemctl --user show-environment"}).Return(nil, 127, nil)
+	m.EXPECT().RunCommand(dotf.Command{Line: "crontab -l"}).Return(nil, 0, nil)
+	m.EXPECT().
+		RunCommand(dotf.Command{
+			Line:  "crontab -",
+			Stdin: []byte(`0 */1 * * * exec '/opt/100\%/dotf' sync < /dev/null >> '/home/.dotf-sync.log' 2>&1 # dotf sync` + "\n"),
+		}).
+		Return(nil, 0, nil)
+	m.EXPECT().Log(gomock.Any())
+
+	err := commands.InstallSchedule(m, commands.ScheduleOptions{Every: time.Hour})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestInstallSchedule_ShouldRejectIntervalsCronCannotExpress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	expectScheduleSetup(m, "")
+	m.EXPECT().Executable().Return("/usr/local/bin/dotf", nil)
+	m.EXPECT().RunCommand(dotf.Command{Line: "systemctl --user show-environment"}).Return(nil, 1, nil)
+
+	err := commands.InstallSchedule(m, commands.ScheduleOptions{Every: 7 * time.Minute})
+
+	if err == nil || !strings.Contains(err.Error(), "crontab cannot run every 7m0s") {
+		t.Fatalf("Expected err about the interval, got %v", err)
+	}
+}
+
+func TestRemoveSchedule_ShouldDisableAndRemoveSystemdUnits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	expectScheduleSetup(m, "")
+	m.EXPECT().PathExists("/home/.config/systemd/user/dotf-sync.timer").Return(true)
+
+	gomock.InOrder(
+		m.EXPECT().RunCommand(dotf.Command{Line: "systemctl --user disable --now dotf-sync.timer"}).Return(nil, 0, nil),
+		m.EXPECT().RemoveFile("/home/.config/systemd/user/dotf-sync.timer").Return(nil),
+		m.EXPECT().RemoveFile("/home/.config/systemd/user/dotf-sync.service").Return(nil),
+		m.EXPECT().RunCommand(dotf.Command{Line: "systemctl --user daemon-reload"}).Return(nil, 0, nil),
+		m.EXPECT().Log("removed systemd timer /home/.config/systemd/user/dotf-sync.timer\n"),
+	)
+
+	err := commands.RemoveSchedule(m)
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestScheduleStatus_ShouldReportMissingSchedule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	expectScheduleSetup(m, "")
+	m.EXPECT().PathExists("/home/.config/systemd/user/dotf-sync.timer").Return(false)
+	m.EXPECT().RunCommand(dotf.Command{Line: "crontab -l"}).Return([]byte("no crontab for user\n"), 1, nil)
+	m.EXPECT().Log("dotf sync is not scheduled\n")
+
+	err := commands.ScheduleStatus(m)
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"strings"
+
+	"bakku.dev/dotf"
+)
+
+// Minimum length and Shannon entropy in bits per character of a token to be
+// reported as a possible secret by the high-entropy rule.
+const (
+	minSecretTokenLength = 32
+	minSecretEntropy     = 4.5
+)
+
+// secretRule flags lines which look like they contain a credential.
+type secretRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+var secretRules = []secretRule{
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY( BLOCK)?-----`)},
+	{"aws-access-key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws-secret-key", regexp.MustCompile(`(?i)aws_secret_access_key\s*[=:]\s*\S+`)},
+	{"github-token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"password", regexp.MustCompile(`(?i)\b(password|passwd|pwd)\s*[=:]\s*\S+`)},
+}
+
+var secretTokenPattern = regexp.MustCompile(`[A-Za-z0-9+/=_-]{32,}`)
+
+// secretFinding is a line of a file which matched a secret rule.
+type secretFinding struct {
+	file string
+	line int
+	rule string
+}
+
+// scanForSecrets checks the added and modified files of the repo for
+// credentials. Findings which are covered by the allow-list of the config are
+// ignored. If anything is found the copied changes are discarded and an error
+// listing the findings is returned.
+func scanForSecrets(sys dotf.SysOpsProvider, cfg dotf.Config, changes dotf.FileChanges) error {
+	copied := append(append([]string{}, changes.Added...), changes.Modified...)
+
+	findings, err := findSecrets(sys, cfg, copied)
+
+	if err != nil || len(findings) == 0 {
+		return err
+	}
+
+	err = sys.DiscardChanges(cfg.Repo, copied)
+
+	if err != nil {
+		return err
+	}
+
+	return secretsFoundError(findings)
+}
+
+// findSecrets scans the given files of the repo worktree for credentials which
+// are not covered by the allow-list of the config and describes each finding.
+// Files which do not exist are skipped.
+func findSecrets(sys dotf.SysOpsProvider, cfg dotf.Config, files []string) ([]string, error) {
+	allowList, err := compileAllowList(cfg.SecretAllowList)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := sys.OpenRepoReader(cfg.Repo, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []string
+
+	for _, file := range files {
+		content, err := readRepoFile(reader, file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, finding := range scanContent(file, content) {
+			if !allowList.allows(finding, content) {
+				findings = append(findings, fmt.Sprintf("%s:%d: %s", finding.file, finding.line, finding.rule))
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+func secretsFoundError(findings []string) error {
+	return fmt.Errorf(
+		"possible secrets found, nothing was committed:\n  %s\nadd rules to secretAllowList in the dotf config to allow false positives",
+		strings.Join(findings, "\n  "),
+	)
+}
+
+// scanContent applies all secret rules to every line of content.
+func scanContent(file string, content []byte) []secretFinding {
+	var findings []secretFinding
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		matched := false
+
+		for _, rule := range secretRules {
+			if rule.pattern.MatchString(line) {
+				findings = append(findings, secretFinding{file: file, line: lineNumber, rule: rule.name})
+				matched = true
+			}
+		}
+
+		// a line that matched a known rule is not reported a second time
+		if matched {
+			continue
+		}
+
+		for _, token := range secretTokenPattern.FindAllString(line, -1) {
+			if isHighEntropy(token) {
+				findings = append(findings, secretFinding{file: file, line: lineNumber, rule: "high-entropy"})
+				break
+			}
+		}
+	}
+
+	return findings
+}
+
+// isHighEntropy reports whether token looks like random data. Tokens need
+// letters and digits so long words and identifiers are not flagged.
+func isHighEntropy(token string) bool {
+	if len(token) < minSecretTokenLength || !strings.ContainsAny(token, "0123456789") ||
+		strings.ToLower(token) == token || strings.ToUpper(token) == token {
+		return false
+	}
+
+	counts := make(map[rune]int)
+
+	for _, r := range token {
+		counts[r]++
+	}
+
+	var entropy float64
+
+	for _, count := range counts {
+		p := float64(count) / float64(len(token))
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy >= minSecretEntropy
+}
+
+// secretAllowEntry is an allow-list rule with its compiled pattern.
+type secretAllowEntry struct {
+	rule    dotf.SecretAllowRule
+	pattern *regexp.Regexp
+}
+
+// secretAllowList is the compiled form of the allow-list of the config.
+type secretAllowList []secretAllowEntry
+
+func compileAllowList(rules []dotf.SecretAllowRule) (secretAllowList, error) {
+	var allowList secretAllowList
+
+	for _, rule := range rules {
+		var pattern *regexp.Regexp
+
+		if rule.Pattern != "" {
+			var err error
+
+			pattern, err = regexp.Compile(rule.Pattern)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q in secretAllowList: %v", rule.Pattern, err)
+			}
+		}
+
+		allowList = append(allowList, secretAllowEntry{rule: rule, pattern: pattern})
+	}
+
+	return allowList, nil
+}
+
+// allows reports whether a finding in content is covered by a rule.
+func (al secretAllowList) allows(finding secretFinding, content []byte) bool {
+	line := strings.Split(string(content), "\n")[finding.line-1]
+
+	for _, entry := range al {
+		if entry.rule.File != "" {
+			matched, _ := path.Match(strings.TrimPrefix(entry.rule.File, "/"), strings.TrimPrefix(finding.file, "/"))
+
+			if !matched {
+				continue
+			}
+		}
+
+		if entry.rule.Rule != "" && entry.rule.Rule != finding.rule {
+			continue
+		}
+
+		if entry.pattern != nil && !entry.pattern.MatchString(line) {
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
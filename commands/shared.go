@@ -26,34 +26,127 @@ func getDotfConfigPath(sys dotf.SysOpsProvider) (string, error) {
 }
 
 func readConfig(sys dotf.SysOpsProvider, dotfilePath string) (dotf.Config, error) {
+	cfg, _, err := readRawConfig(sys, dotfilePath)
+
+	return cfg, err
+}
+
+// readRawConfig returns the dotf config together with the content of the
+// config file it was deserialized from.
+func readRawConfig(sys dotf.SysOpsProvider, dotfilePath string) (dotf.Config, []byte, error) {
 	rawConfig, err := sys.ReadFile(dotfilePath)
 
 	if err != nil {
-		return dotf.Config{}, fmt.Errorf("could not read dotf config: %v", err)
+		return dotf.Config{}, nil, fmt.Errorf("could not read dotf config: %v", err)
 	}
 
 	cfg := dotf.Config{}
 	err = sys.DeserializeConfig(rawConfig, &cfg)
 
 	if err != nil {
-		return dotf.Config{}, fmt.Errorf("could not deserialize dotf config: %v", err)
+		return dotf.Config{}, nil, fmt.Errorf("could not deserialize dotf config: %v", err)
+	}
+
+	return cfg, rawConfig, nil
+}
+
+// configUpdateAttempts is how often updateConfig reapplies an update which
+// conflicted with a concurrent change of the config.
+const configUpdateAttempts = 5
+
+// updateConfig reads the dotf config, applies update to it and writes it back
+// if the config file did not change in the meantime. Otherwise the update is
+// applied again to the new config, so concurrent changes are never lost.
+func updateConfig(sys dotf.SysOpsProvider, dotfilePath string, update func(cfg *dotf.Config) error) error {
+	for attempt := 0; attempt < configUpdateAttempts; attempt++ {
+		cfg, rawConfig, err := readRawConfig(sys, dotfilePath)
+
+		if err != nil {
+			return err
+		}
+
+		err = update(&cfg)
+
+		if err != nil {
+			return err
+		}
+
+		newRawConfig, err := sys.SerializeConfig(cfg)
+
+		if err != nil {
+			return fmt.Errorf("could not serialize dotf config: %v", err)
+		}
+
+		err = sys.ReplaceFile(dotfilePath, rawConfig, newRawConfig)
+
+		if errors.Is(err, dotf.ErrConflict) {
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not write dotf config: %v", err)
+		}
+
+		return nil
 	}
 
-	return cfg, nil
+	return fmt.Errorf(
+		"dotf config was changed by other processes %d times while updating it, nothing was written",
+		configUpdateAttempts,
+	)
 }
 
-func writeConfig(sys dotf.SysOpsProvider, dotfilePath string, cfg dotf.Config) error {
-	newRawConfig, err := sys.SerializeConfig(cfg)
+// findTrackedFile returns the tracked file which is stored at the given path
+// on the system.
+func findTrackedFile(sys dotf.SysOpsProvider, cfg dotf.Config, systemFilePath string) (dotf.TrackedFile, error) {
+	absoluteSystemFilePath, err := sys.ExpandPath(systemFilePath)
 
 	if err != nil {
-		return fmt.Errorf("could not serialize dotf config: %v", err)
+		return dotf.TrackedFile{}, fmt.Errorf("could not build absolute path: %v", err)
+	}
+
+	for _, tf := range cfg.TrackedFiles {
+		if tf.PathOnSystem == absoluteSystemFilePath {
+			return tf, nil
+		}
+	}
+
+	return dotf.TrackedFile{}, fmt.Errorf("%s is not a tracked file", systemFilePath)
+}
+
+// shortHash abbreviates a commit hash like git does.
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+
+	return hash
+}
+
+// readRepoFile reads a file through reader and returns nil if it does not
+// exist in the repo.
+func readRepoFile(reader dotf.RepoReader, pathInRepo string) ([]byte, error) {
+	content, err := reader.ReadFile(pathInRepo)
+
+	if errors.Is(err, dotf.ErrNotInRepo) {
+		return nil, nil
+	}
+
+	return content, err
+}
+
+// readSystemFile reads a file on the system and returns nil if it does not
+// exist.
+func readSystemFile(sys dotf.SysOpsProvider, path string) ([]byte, error) {
+	if !sys.PathExists(path) {
+		return nil, nil
 	}
 
-	err = sys.WriteFile(dotfilePath, newRawConfig)
+	content, err := sys.ReadFile(path)
 
 	if err != nil {
-		return fmt.Errorf("could not write dotf config: %v", err)
+		return nil, fmt.Errorf("could not read %s: %v", path, err)
 	}
 
-	return nil
+	return content, nil
 }
@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"bakku.dev/dotf"
+)
+
+// snapshotTagPrefix namespaces the tags created for snapshots so they do not
+// collide with other tags of the repo.
+const snapshotTagPrefix = "snapshot/"
+
+// Snapshot pushes all tracked files and tags the resulting commit so the
+// current state can be restored later.
+func Snapshot(sys dotf.SysOpsProvider, name string) error {
+	dotfilePath, err := getDotfConfigPath(sys)
+
+	if err != nil {
+		return fmt.Errorf("snapshot: %v", err)
+	}
+
+	return createSnapshot(sys, dotfilePath, name)
+}
+
+func createSnapshot(sys dotf.SysOpsProvider, dotfilePath, name string) error {
+	if name == "" || strings.ContainsAny(name, " ~^:?*[\\") || strings.Contains(name, "..") {
+		return fmt.Errorf("snapshot: %q is not a valid snapshot name", name)
+	}
+
+	cfg, err := readConfig(sys, dotfilePath)
+
+	if err != nil {
+		return fmt.Errorf("snapshot: %v", err)
+	}
+
+	files, err := copyToRepo(sys, cfg, DefaultJobs)
+
+	if err != nil {
+		return fmt.Errorf("snapshot: %v", err)
+	}
+
+	_, err = commitToRepo(sys, cfg, files, "")
+
+	if err != nil {
+		return fmt.Errorf("snapshot: %v", err)
+	}
+
+	err = sys.PushRepo(cfg.Repo, cfg.Git)
+
+	if err != nil {
+		return fmt.Errorf("snapshot: %v", err)
+	}
+
+	tag := snapshotTagPrefix + name
+	err = sys.CreateTag(cfg.Repo, tag, fmt.Sprintf("Snapshot %s", name), cfg.Git)
+
+	if err != nil {
+		return fmt.Errorf("snapshot: %v", err)
+	}
+
+	err = sys.PushTag(cfg.Repo, tag, cfg.Git)
+
+	if err != nil {
+		return fmt.Errorf("snapshot: %v", err)
+	}
+
+	sys.Log(fmt.Sprintf("created snapshot %s\n", name))
+
+	return nil
+}
+
+// ListSnapshotsOptions configures the snapshot list command.
+type ListSnapshotsOptions struct {
+	// Output is the output format, OutputTable if it is empty.
+	Output string
+}
+
+// SnapshotReport is a snapshot in the JSON output.
+type SnapshotReport struct {
+	Name   string    `json:"name"`
+	Commit string    `json:"commit"`
+	Date   time.Time `json:"date"`
+}
+
+// SnapshotListReport is the JSON output of the snapshot list command.
+type SnapshotListReport struct {
+	Snapshots []SnapshotReport `json:"snapshots"`
+}
+
+// ListSnapshots shows all snapshots, newest first.
+func ListSnapshots(sys dotf.SysOpsProvider, opts ListSnapshotsOptions) error {
+	err := checkOutput(opts.Output)
+
+	if err != nil {
+		return fmt.Errorf("snapshot list: %v", err)
+	}
+
+	dotfilePath, err := getDotfConfigPath(sys)
+
+	if err != nil {
+		return fmt.Errorf("snapshot list: %v", err)
+	}
+
+	return listSnapshots(sys, dotfilePath, opts)
+}
+
+func listSnapshots(sys dotf.SysOpsProvider, dotfilePath string, opts ListSnapshotsOptions) error {
+	cfg, err := readConfig(sys, dotfilePath)
+
+	if err != nil {
+		return fmt.Errorf("snapshot list: %v", err)
+	}
+
+	err = sys.FetchRepo(cfg.Repo, cfg.Git)
+
+	if err != nil {
+		return fmt.Errorf("snapshot list: %v", err)
+	}
+
+	tags, err := sys.ListTags(cfg.Repo, snapshotTagPrefix)
+
+	if err != nil {
+		return fmt.Errorf("snapshot list: %v", err)
+	}
+
+	if opts.Output == OutputJSON {
+		report := SnapshotListReport{Snapshots: []SnapshotReport{}}
+
+		for _, tag := range tags {
+			report.Snapshots = append(report.Snapshots, SnapshotReport{
+				Name:   strings.TrimPrefix(tag.Name, snapshotTagPrefix),
+				Commit: tag.Commit,
+				Date:   tag.When,
+			})
+		}
+
+		err = logJSON(sys, report)
+
+		if err != nil {
+			return fmt.Errorf("snapshot list: %v", err)
+		}
+
+		return nil
+	}
+
+	if len(tags) == 0 && opts.Output != OutputPlain {
+		sys.Log("no snapshots\n")
+
+		return nil
+	}
+
+	var rows [][]string
+
+	for _, tag := range tags {
+		rows = append(rows, []string{
+			strings.TrimPrefix(tag.Name, snapshotTagPrefix),
+			shortHash(tag.Commit),
+			tag.When.Format("2006-01-02 15:04"),
+		})
+	}
+
+	sys.Log(renderRows(opts.Output, []string{"Snapshot", "Commit", "Date"}, rows, true))
+
+	return nil
+}
+
+// RestoreSnapshot deploys all tracked files as they were in a snapshot. The
+// files on the system are backed up first.
+func RestoreSnapshot(sys dotf.SysOpsProvider, name string) error {
+	dotfilePath, err := getDotfConfigPath(sys)
+
+	if err != nil {
+		return fmt.Errorf("snapshot restore: %v", err)
+	}
+
+	return restoreSnapshot(sys, dotfilePath, name)
+}
+
+func restoreSnapshot(sys dotf.SysOpsProvider, dotfilePath, name string) error {
+	cfg, err := readConfig(sys, dotfilePath)
+
+	if err != nil {
+		return fmt.Errorf("snapshot restore: %v", err)
+	}
+
+	err = sys.FetchRepo(cfg.Repo, cfg.Git)
+
+	if err != nil {
+		return fmt.Errorf("snapshot restore: %v", err)
+	}
+
+	cfg.CreateBackups = true
+	revision, _, err := deployRevision(sys, dotfilePath, cfg, snapshotTagPrefix+name, operationRestore, DefaultJobs)
+
+	if err != nil {
+		return fmt.Errorf("snapshot restore: %v", err)
+	}
+
+	sys.Log(fmt.Sprintf("restored snapshot %s (%s), the previous files are backed up as .bk\n", name, shortHash(revision)))
+
+	return nil
+}
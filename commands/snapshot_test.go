@@ -0,0 +1,204 @@
+package commands_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"bakku.dev/dotf"
+	"bakku.dev/dotf/commands"
+	"bakku.dev/dotf/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+func TestSnapshot_ShouldFailIfNoHomeVarExists(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("")
+
+	err := commands.Snapshot(m, "before-zsh")
+
+	if err == nil {
+		t.Fatalf("Expected err to not be nil")
+	}
+}
+
+func TestSnapshot_ShouldRejectInvalidNames(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+
+	err := commands.Snapshot(m, "before zsh")
+
+	if err == nil {
+		t.Fatalf("Expected err not to be nil")
+	}
+}
+
+func TestSnapshot_ShouldPushAndTag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/").Times(2)
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc")
+
+	gomock.InOrder(
+		m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(nil),
+		m.EXPECT().RepoChanges("/home/repo", []string{"/.vimrc"}).Return(dotf.FileChanges{}, nil),
+		m.EXPECT().PushRepo("/home/repo", dotf.GitConfig{}).Return(nil),
+		m.EXPECT().CreateTag("/home/repo", "snapshot/before-zsh", "Snapshot before-zsh", dotf.GitConfig{}).Return(nil),
+		m.EXPECT().PushTag("/home/repo", "snapshot/before-zsh", dotf.GitConfig{}).Return(nil),
+		m.EXPECT().Log("created snapshot before-zsh\n"),
+	)
+
+	err := commands.Snapshot(m, "before-zsh")
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestSnapshot_ShouldFailIfTagExists(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{Repo: "/home/repo"}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().RepoChanges("/home/repo", nil).Return(dotf.FileChanges{}, nil)
+	m.EXPECT().PushRepo("/home/repo", dotf.GitConfig{}).Return(nil)
+	m.EXPECT().
+		CreateTag("/home/repo", "snapshot/before-zsh", "Snapshot before-zsh", dotf.GitConfig{}).
+		Return(errors.New("tag snapshot/before-zsh already exists in repo /home/repo"))
+
+	err := commands.Snapshot(m, "before-zsh")
+
+	if err == nil {
+		t.Fatalf("Expected err not to be nil")
+	}
+}
+
+func TestListSnapshots_ShouldShowSnapshots(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{Repo: "/home/repo"}
+
+	tags := []dotf.Tag{
+		{
+			Name:   "snapshot/before-zsh",
+			Commit: "8f3a2c1d9e0b7a6f5c4d3e2f1a0b9c8d7e6f5a4b",
+			When:   time.Date(2020, 11, 2, 9, 15, 0, 0, time.UTC),
+		},
+		{
+			Name:   "snapshot/clean",
+			Commit: "1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e",
+			When:   time.Date(2020, 10, 30, 18, 0, 0, 0, time.UTC),
+		},
+	}
+
+	expectedTableString := "" +
+		"+------------+---------+------------------+\n" +
+		"|  SNAPSHOT  | COMMIT  |       DATE       |\n" +
+		"+------------+---------+------------------+\n" +
+		"| before-zsh | 8f3a2c1 | 2020-11-02 09:15 |\n" +
+		"| clean      | 1b2c3d4 | 2020-10-30 18:00 |\n" +
+		"+------------+---------+------------------+\n"
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().FetchRepo("/home/repo", dotf.GitConfig{}).Return(nil)
+	m.EXPECT().ListTags("/home/repo", "snapshot/").Return(tags, nil)
+	m.EXPECT().Log(expectedTableString)
+
+	err := commands.ListSnapshots(m, commands.ListSnapshotsOptions{})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestRestoreSnapshot_ShouldDeployTagWithBackups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().FetchRepo("/home/repo", dotf.GitConfig{}).Return(nil)
+	expectRepoReader(ctrl, m, "snapshot/before-zsh", map[string]string{".vimrc": "set number"})
+
+	gomock.InOrder(
+		m.EXPECT().CopyFile("/home/.vimrc", "/home/.vimrc.bk").Return(nil),
+		m.EXPECT().WriteFile("/home/.vimrc", []byte("set number")).Return(nil),
+	)
+
+	expectDeployedRevision(m, cfg, "abc123")
+	m.EXPECT().Log("restored snapshot before-zsh (abc123), the previous files are backed up as .bk\n")
+
+	err := commands.RestoreSnapshot(m, "before-zsh")
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+
+	"bakku.dev/dotf"
+)
+
+// StatusOptions configures the status command.
+type StatusOptions struct {
+	// Rev compares the system against the given commit, tag or branch instead
+	// of the worktree of the repo.
+	Rev string
+	// Output is the output format, OutputTable if it is empty.
+	Output string
+}
+
+// FileStateReport is a tracked file and its state in the JSON output. State
+// is one of "unchanged", "modified", "missing on system", "not in repo" or
+// "missing".
+type FileStateReport struct {
+	TrackedFileReport
+	State string `json:"state"`
+}
+
+// StatusReport is the JSON output of the status command.
+type StatusReport struct {
+	Files            []FileStateReport `json:"files"`
+	DeployedRevision string            `json:"deployedRevision"`
+	UnpushedCommits  int               `json:"unpushedCommits"`
+}
+
+// Status shows which tracked files differ between system and repo and how
+// many commits are not pushed yet.
+func Status(sys dotf.SysOpsProvider, opts StatusOptions) error {
+	err := checkOutput(opts.Output)
+
+	if err != nil {
+		return fmt.Errorf("status: %v", err)
+	}
+
+	dotfilePath, err := getDotfConfigPath(sys)
+
+	if err != nil {
+		return fmt.Errorf("status: %v", err)
+	}
+
+	return showStatus(sys, dotfilePath, opts)
+}
+
+func showStatus(sys dotf.SysOpsProvider, dotfilePath string, opts StatusOptions) error {
+	cfg, err := readConfig(sys, dotfilePath)
+
+	if err != nil {
+		return fmt.Errorf("status: %v", err)
+	}
+
+	reader, err := sys.OpenRepoReader(cfg.Repo, opts.Rev)
+
+	if err != nil {
+		return fmt.Errorf("status: %v", err)
+	}
+
+	report := StatusReport{Files: []FileStateReport{}, DeployedRevision: cfg.DeployedRevision}
+
+	var rows [][]string
+
+	for _, tf := range cfg.TrackedFiles {
+		state, err := trackedFileState(sys, reader, tf)
+
+		if err != nil {
+			return fmt.Errorf("status: %v", err)
+		}
+
+		report.Files = append(report.Files, FileStateReport{
+			TrackedFileReport: TrackedFileReport{PathOnSystem: tf.PathOnSystem, PathInRepo: tf.PathInRepo},
+			State:             state,
+		})
+		rows = append(rows, []string{tf.PathOnSystem, tf.PathInRepo, state})
+	}
+
+	if opts.Output == OutputPlain {
+		// plain output only consists of one row per tracked file
+		sys.Log(renderRows(opts.Output, nil, rows, true))
+
+		return nil
+	}
+
+	if opts.Output != OutputJSON {
+		sys.Log(renderRows(opts.Output, []string{"File", "Path in repo", "State"}, rows, true))
+
+		if cfg.DeployedRevision != "" {
+			sys.Log(fmt.Sprintf("deployed revision: %s\n", cfg.DeployedRevision))
+		}
+	}
+
+	count, err := sys.UnpushedCommits(cfg.Repo, cfg.Git)
+
+	if err != nil {
+		return fmt.Errorf("status: %v", err)
+	}
+
+	if opts.Output == OutputJSON {
+		report.UnpushedCommits = count
+		err = logJSON(sys, report)
+
+		if err != nil {
+			return fmt.Errorf("status: %v", err)
+		}
+
+		return nil
+	}
+
+	if count > 0 {
+		sys.Log(fmt.Sprintf("%d local commit(s) not pushed yet\n", count))
+	} else {
+		sys.Log("all commits are pushed\n")
+	}
+
+	return nil
+}
+
+// trackedFileState compares the system and repo copy of a tracked file.
+func trackedFileState(sys dotf.SysOpsProvider, reader dotf.RepoReader, tf dotf.TrackedFile) (string, error) {
+	systemContent, err := readSystemFile(sys, tf.PathOnSystem)
+
+	if err != nil {
+		return "", err
+	}
+
+	repoContent, err := readRepoFile(reader, tf.PathInRepo)
+
+	if err != nil {
+		return "", err
+	}
+
+	return contentState(systemContent, repoContent), nil
+}
+
+// contentState describes how the system copy of a tracked file differs from
+// its repo copy. nil contents do not exist.
+func contentState(systemContent, repoContent []byte) string {
+	switch {
+	case systemContent == nil && repoContent == nil:
+		return "missing"
+	case systemContent == nil:
+		return "missing on system"
+	case repoContent == nil:
+		return "not in repo"
+	case bytes.Equal(systemContent, repoContent):
+		return "unchanged"
+	default:
+		return "modified"
+	}
+}
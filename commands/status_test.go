@@ -0,0 +1,270 @@
+package commands_test
+
+import (
+	"errors"
+	"testing"
+
+	"bakku.dev/dotf"
+	"bakku.dev/dotf/commands"
+	"bakku.dev/dotf/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+func TestStatus_ShouldFailIfNoHomeVarExists(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("")
+
+	err := commands.Status(m, commands.StatusOptions{})
+
+	if err == nil {
+		t.Fatalf("Expected err to not be nil")
+	}
+}
+
+func TestStatus_ShouldFailIfConfigCannotBeRead(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return(nil, errors.New("error"))
+
+	err := commands.Status(m, commands.StatusOptions{})
+
+	if err == nil {
+		t.Fatalf("Expected err not to be nil")
+	}
+}
+
+func TestStatus_ShouldFailIfUnpushedCommitsCannotBeCounted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{Repo: "/home/repo"}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().OpenRepoReader("/home/repo", "").Return(mocks.NewMockRepoReader(ctrl), nil)
+	m.EXPECT().Log(gomock.Any())
+	m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(0, errors.New("error"))
+
+	err := commands.Status(m, commands.StatusOptions{})
+
+	if err == nil {
+		t.Fatalf("Expected err not to be nil")
+	}
+}
+
+func TestStatus_ShouldShowFileStatesAndUnpushedCommits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := dotf.Config{
+		Repo:             "/home/repo",
+		DeployedRevision: "abc123",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"},
+			{PathInRepo: ".bashrc", PathOnSystem: "/home/.bashrc"},
+			{PathInRepo: ".zshrc", PathOnSystem: "/home/.zshrc"},
+		},
+	}
+
+	expectedTableString := "" +
+		"+---------------+--------------+-------------------+\n" +
+		"|     FILE      | PATH IN REPO |       STATE       |\n" +
+		"+---------------+--------------+-------------------+\n" +
+		"| /home/.vimrc  | .vimrc       | modified          |\n" +
+		"| /home/.bashrc | .bashrc      | unchanged         |\n" +
+		"| /home/.zshrc  | .zshrc       | missing on system |\n" +
+		"+---------------+--------------+-------------------+\n"
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+	reader := mocks.NewMockRepoReader(ctrl)
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().OpenRepoReader("/home/repo", "").Return(reader, nil)
+	m.EXPECT().PathExists("/home/.vimrc").Return(true)
+	m.EXPECT().ReadFile("/home/.vimrc").Return([]byte("set number"), nil)
+	reader.EXPECT().ReadFile(".vimrc").Return([]byte("set nonumber"), nil)
+	m.EXPECT().PathExists("/home/.bashrc").Return(true)
+	m.EXPECT().ReadFile("/home/.bashrc").Return([]byte("PS1=$"), nil)
+	reader.EXPECT().ReadFile(".bashrc").Return([]byte("PS1=$"), nil)
+	m.EXPECT().PathExists("/home/.zshrc").Return(false)
+	reader.EXPECT().ReadFile(".zshrc").Return([]byte("export EDITOR=vim"), nil)
+	m.EXPECT().Log(expectedTableString)
+	m.EXPECT().Log("deployed revision: abc123\n")
+	m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(3, nil)
+	m.EXPECT().Log("3 local commit(s) not pushed yet\n")
+
+	err := commands.Status(m, commands.StatusOptions{})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestStatus_ShouldOnlyShowFileRowsAsPlainOutput(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := dotf.Config{
+		Repo:             "/home/repo",
+		DeployedRevision: "abc123",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+	reader := mocks.NewMockRepoReader(ctrl)
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().OpenRepoReader("/home/repo", "").Return(reader, nil)
+	m.EXPECT().PathExists("/home/.vimrc").Return(true)
+	m.EXPECT().ReadFile("/home/.vimrc").Return([]byte("set number"), nil)
+	reader.EXPECT().ReadFile(".vimrc").Return([]byte("set nonumber"), nil)
+	m.EXPECT().Log("/home/.vimrc\t.vimrc\tmodified\n")
+
+	err := commands.Status(m, commands.StatusOptions{Output: commands.OutputPlain})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestStatus_ShouldCompareAgainstRevision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	expectedTableString := "" +
+		"+--------------+--------------+-------------+\n" +
+		"|     FILE     | PATH IN REPO |    STATE    |\n" +
+		"+--------------+--------------+-------------+\n" +
+		"| /home/.vimrc | .vimrc       | not in repo |\n" +
+		"+--------------+--------------+-------------+\n"
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+	reader := mocks.NewMockRepoReader(ctrl)
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().OpenRepoReader("/home/repo", "v1").Return(reader, nil)
+	m.EXPECT().PathExists("/home/.vimrc").Return(true)
+	m.EXPECT().ReadFile("/home/.vimrc").Return([]byte("set number"), nil)
+	reader.EXPECT().ReadFile(".vimrc").Return(nil, dotf.ErrNotInRepo)
+	m.EXPECT().Log(expectedTableString)
+	m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(0, nil)
+	m.EXPECT().Log("all commits are pushed\n")
+
+	err := commands.Status(m, commands.StatusOptions{Rev: "v1"})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestStatus_ShouldShowJSONReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := dotf.Config{
+		Repo:             "/home/repo",
+		DeployedRevision: "abc123",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: ".vimrc", PathOnSystem: "/home/.vimrc"},
+			{PathInRepo: ".zshrc", PathOnSystem: "/home/.zshrc"},
+		},
+	}
+
+	expected := "{\n" +
+		"  \"files\": [\n" +
+		"    {\n" +
+		"      \"pathOnSystem\": \"/home/.vimrc\",\n" +
+		"      \"pathInRepo\": \".vimrc\",\n" +
+		"      \"state\": \"modified\"\n" +
+		"    },\n" +
+		"    {\n" +
+		"      \"pathOnSystem\": \"/home/.zshrc\",\n" +
+		"      \"pathInRepo\": \".zshrc\",\n" +
+		"      \"state\": \"not in repo\"\n" +
+		"    }\n" +
+		"  ],\n" +
+		"  \"deployedRevision\": \"abc123\",\n" +
+		"  \"unpushedCommits\": 2\n" +
+		"}\n"
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+	reader := mocks.NewMockRepoReader(ctrl)
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().OpenRepoReader("/home/repo", "").Return(reader, nil)
+	m.EXPECT().PathExists("/home/.vimrc").Return(true)
+	m.EXPECT().ReadFile("/home/.vimrc").Return([]byte("set number"), nil)
+	reader.EXPECT().ReadFile(".vimrc").Return([]byte("set nonumber"), nil)
+	m.EXPECT().PathExists("/home/.zshrc").Return(true)
+	m.EXPECT().ReadFile("/home/.zshrc").Return([]byte("export EDITOR=vim"), nil)
+	reader.EXPECT().ReadFile(".zshrc").Return(nil, dotf.ErrNotInRepo)
+	m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(2, nil)
+	m.EXPECT().Log(expected)
+
+	err := commands.Status(m, commands.StatusOptions{Output: commands.OutputJSON})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
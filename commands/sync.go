@@ -0,0 +1,158 @@
+package commands
+
+import (
+	"fmt"
+
+	"bakku.dev/dotf"
+)
+
+// SyncOptions configures the sync command.
+type SyncOptions struct {
+	// Message is used as commit message for local changes. If it is empty a
+	// message describing the changes is generated.
+	Message string
+	// Strategy overrides the pull strategy of the config. Sync rebases by
+	// default.
+	Strategy string
+	// Jobs is how many tracked files are validated, copied and deployed at the
+	// same time. DefaultJobs is used if it is zero.
+	Jobs int
+}
+
+// Sync commits local changes, combines them with the remote changes, pushes
+// the result and deploys it onto the system. Local changes are the tracked
+// files which differ from the deployed revision. Both the push and the pull
+// hooks run.
+func Sync(sys dotf.SysOpsProvider, opts SyncOptions) error {
+	dotfilePath, err := getDotfConfigPath(sys)
+
+	if err != nil {
+		return fmt.Errorf("sync: %v", err)
+	}
+
+	return syncDotfiles(sys, dotfilePath, opts)
+}
+
+func syncDotfiles(sys dotf.SysOpsProvider, dotfilePath string, opts SyncOptions) error {
+	cfg, err := readConfig(sys, dotfilePath)
+
+	if err != nil {
+		return fmt.Errorf("sync: %v", err)
+	}
+
+	if opts.Strategy != "" {
+		cfg.Git.PullStrategy = opts.Strategy
+	}
+
+	switch cfg.Git.PullStrategy {
+	case "":
+		cfg.Git.PullStrategy = dotf.PullRebase
+	case dotf.PullReset:
+		return fmt.Errorf("sync: the %s strategy would drop local changes, use pull instead", dotf.PullReset)
+	}
+
+	err = runPreHook(sys, cfg, operationSync, hookPrePush, cfg.Hooks.PrePush)
+
+	if err != nil {
+		return fmt.Errorf("sync: %v", err)
+	}
+
+	err = runPreHook(sys, cfg, operationSync, hookPrePull, cfg.Hooks.PrePull)
+
+	if err != nil {
+		return fmt.Errorf("sync: %v", err)
+	}
+
+	err = cleanRepo(sys, cfg, DirtyAbort)
+
+	if err != nil {
+		return fmt.Errorf("sync: %v", err)
+	}
+
+	local, err := locallyChangedFiles(sys, cfg)
+
+	if err != nil {
+		return fmt.Errorf("sync: %v", err)
+	}
+
+	var changes dotf.FileChanges
+
+	if len(local.TrackedFiles) > 0 {
+		files, err := copyToRepo(sys, local, opts.Jobs)
+
+		if err != nil {
+			return fmt.Errorf("sync: %v", err)
+		}
+
+		changes, err = commitToRepo(sys, local, files, opts.Message)
+
+		if err != nil {
+			return fmt.Errorf("sync: %v", err)
+		}
+	}
+
+	if !changes.Empty() {
+		sys.Log("committed local changes\n")
+	}
+
+	err = sys.UpdateRepo(cfg.Repo, cfg.Git)
+
+	if err != nil {
+		return fmt.Errorf(
+			"sync: local changes are committed but could not be combined with the remote, nothing was pushed or deployed: %v",
+			err,
+		)
+	}
+
+	err = pushQueuedCommits(sys, cfg)
+
+	if err != nil {
+		return fmt.Errorf("sync: remote changes are pulled but local changes could not be pushed, nothing was deployed: %v", err)
+	}
+
+	runPostHook(sys, cfg, operationSync, hookPostPush, cfg.Hooks.PostPush, committedFiles(cfg, changes))
+
+	_, changed, err := deployRevision(sys, dotfilePath, cfg, "HEAD", operationSync, opts.Jobs)
+
+	if err != nil {
+		return fmt.Errorf("sync: %v", err)
+	}
+
+	runPostHook(sys, cfg, operationSync, hookPostPull, cfg.Hooks.PostPull, changed)
+
+	return nil
+}
+
+// locallyChangedFiles returns cfg with only the tracked files whose system copy
+// differs from the deployed revision, the changes since the last sync or pull.
+// Files which match it are no local edits even if an older commit is deployed,
+// e.g. by pull --rev or snapshot restore. Without a deployed revision all
+// tracked files are kept.
+func locallyChangedFiles(sys dotf.SysOpsProvider, cfg dotf.Config) (dotf.Config, error) {
+	if cfg.DeployedRevision == "" {
+		return cfg, nil
+	}
+
+	reader, err := sys.OpenRepoReader(cfg.Repo, cfg.DeployedRevision)
+
+	if err != nil {
+		return dotf.Config{}, fmt.Errorf("could not read deployed revision %s: %v", cfg.DeployedRevision, err)
+	}
+
+	local := cfg
+	local.TrackedFiles = nil
+
+	for _, tf := range cfg.TrackedFiles {
+		state, err := trackedFileState(sys, reader, tf)
+
+		if err != nil {
+			return dotf.Config{}, err
+		}
+
+		if state != "unchanged" {
+			local.TrackedFiles = append(local.TrackedFiles, tf)
+		}
+	}
+
+	return local, nil
+}
@@ -0,0 +1,214 @@
+package commands_test
+
+import (
+	"errors"
+	"testing"
+
+	"bakku.dev/dotf"
+	"bakku.dev/dotf/commands"
+	"bakku.dev/dotf/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+func TestSync_ShouldFailIfNoHomeVarExists(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("")
+
+	err := commands.Sync(m, commands.SyncOptions{})
+
+	if err == nil {
+		t.Fatalf("Expected err to not be nil")
+	}
+}
+
+func TestSync_ShouldRefuseResetStrategy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{Repo: "/home/repo", Git: dotf.GitConfig{PullStrategy: dotf.PullReset}}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+
+	err := commands.Sync(m, commands.SyncOptions{})
+
+	if err == nil {
+		t.Fatalf("Expected err not to be nil")
+	}
+}
+
+func TestSync_ShouldStopWithoutPushingIfRemoteChangesConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	gitCfg := dotf.GitConfig{PullStrategy: dotf.PullRebase}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/").Times(2)
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().DirtyFiles("/home/repo").Return(nil, nil)
+	m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc")
+	m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(nil)
+	m.EXPECT().RepoChanges("/home/repo", []string{"/.vimrc"}).Return(dotf.FileChanges{Modified: []string{".vimrc"}}, nil)
+	expectSecretScan(ctrl, m)
+	m.EXPECT().CommitRepo("/home/repo", "Update .vimrc", []string{"/.vimrc"}, gitCfg).Return(nil)
+	m.EXPECT().Log("committed local changes\n")
+	m.EXPECT().UpdateRepo("/home/repo", gitCfg).Return(errors.New("local and remote commits both changed .vimrc"))
+
+	err := commands.Sync(m, commands.SyncOptions{Message: "Update .vimrc"})
+
+	if err == nil {
+		t.Fatalf("Expected err not to be nil")
+	}
+}
+
+func TestSync_ShouldCommitPullPushAndDeploy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+	}
+
+	gitCfg := dotf.GitConfig{PullStrategy: dotf.PullMerge}
+	reader := mocks.NewMockRepoReader(ctrl)
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/").Times(2)
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc")
+
+	gomock.InOrder(
+		m.EXPECT().DirtyFiles("/home/repo").Return(nil, nil),
+		m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(nil),
+		m.EXPECT().RepoChanges("/home/repo", []string{"/.vimrc"}).Return(dotf.FileChanges{Modified: []string{".vimrc"}}, nil),
+		m.EXPECT().OpenRepoReader("/home/repo", "").Return(reader, nil),
+		reader.EXPECT().ReadFile(".vimrc").Return([]byte("set number"), nil),
+		m.EXPECT().CommitRepo("/home/repo", "Update .vimrc", []string{"/.vimrc"}, gitCfg).Return(nil),
+		m.EXPECT().Log("committed local changes\n"),
+		m.EXPECT().UpdateRepo("/home/repo", gitCfg).Return(nil),
+		m.EXPECT().UnpushedCommits("/home/repo", gitCfg).Return(2, nil),
+		m.EXPECT().Log("pushing 2 queued commit(s)\n"),
+		m.EXPECT().PushRepo("/home/repo", gitCfg).Return(nil),
+		m.EXPECT().ResolveRevision("/home/repo", "HEAD").Return("abc123", nil),
+		m.EXPECT().OpenRepoReader("/home/repo", "abc123").Return(reader, nil),
+		reader.EXPECT().ReadFile("/.vimrc").Return([]byte("set number"), nil),
+		m.EXPECT().WriteFile("/home/.vimrc", []byte("set number")).Return(nil),
+	)
+
+	expectDeployedRevision(m, cfg, "abc123")
+
+	err := commands.Sync(m, commands.SyncOptions{Message: "Update .vimrc", Strategy: dotf.PullMerge})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestSync_ShouldOnlyCommitFilesChangedSinceDeployedRevision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	// pull --rev old123 deployed an older .vimrc, only .bashrc was edited since
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+			{PathInRepo: "/.bashrc", PathOnSystem: "/home/.bashrc"},
+		},
+		DeployedRevision: "old123",
+	}
+
+	gitCfg := dotf.GitConfig{PullStrategy: dotf.PullRebase}
+	deployed := mocks.NewMockRepoReader(ctrl)
+	worktree := mocks.NewMockRepoReader(ctrl)
+	head := mocks.NewMockRepoReader(ctrl)
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/").Times(2)
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().PathExists("/home/.vimrc").Return(true)
+	m.EXPECT().ReadFile("/home/.vimrc").Return([]byte("set nonumber"), nil)
+	m.EXPECT().PathExists("/home/.bashrc").Return(true)
+	m.EXPECT().ReadFile("/home/.bashrc").Return([]byte("alias ll='ls -l'"), nil)
+	deployed.EXPECT().ReadFile("/.vimrc").Return([]byte("set nonumber"), nil)
+	deployed.EXPECT().ReadFile("/.bashrc").Return([]byte("alias l='ls'"), nil)
+	m.EXPECT().CleanPath("/home/repo//.bashrc").Return("/home/repo/.bashrc")
+
+	gomock.InOrder(
+		m.EXPECT().DirtyFiles("/home/repo").Return(nil, nil),
+		m.EXPECT().OpenRepoReader("/home/repo", "old123").Return(deployed, nil),
+		m.EXPECT().CopyFile("/home/.bashrc", "/home/repo/.bashrc").Return(nil),
+		m.EXPECT().RepoChanges("/home/repo", []string{"/.bashrc"}).Return(dotf.FileChanges{Modified: []string{".bashrc"}}, nil),
+		m.EXPECT().OpenRepoReader("/home/repo", "").Return(worktree, nil),
+		worktree.EXPECT().ReadFile(".bashrc").Return([]byte("alias ll='ls -l'"), nil),
+		m.EXPECT().CommitRepo("/home/repo", "Update .bashrc", []string{"/.bashrc"}, gitCfg).Return(nil),
+		m.EXPECT().Log("committed local changes\n"),
+		m.EXPECT().UpdateRepo("/home/repo", gitCfg).Return(nil),
+		m.EXPECT().UnpushedCommits("/home/repo", gitCfg).Return(1, nil),
+		m.EXPECT().Log("pushing 1 queued commit(s)\n"),
+		m.EXPECT().PushRepo("/home/repo", gitCfg).Return(nil),
+		m.EXPECT().ResolveRevision("/home/repo", "HEAD").Return("abc123", nil),
+		m.EXPECT().OpenRepoReader("/home/repo", "abc123").Return(head, nil),
+	)
+
+	head.EXPECT().ReadFile("/.vimrc").Return([]byte("set number"), nil)
+	head.EXPECT().ReadFile("/.bashrc").Return([]byte("alias ll='ls -l'"), nil)
+	m.EXPECT().WriteFile("/home/.vimrc", []byte("set number")).Return(nil)
+	m.EXPECT().WriteFile("/home/.bashrc", []byte("alias ll='ls -l'")).Return(nil)
+
+	expectDeployedRevision(m, cfg, "abc123")
+
+	err := commands.Sync(m, commands.SyncOptions{Message: "Update .bashrc"})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
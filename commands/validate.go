@@ -0,0 +1,198 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"bakku.dev/dotf"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// validateSystemFiles runs the validators of the config on the tracked files
+// on the system, up to jobs files at the same time. Files which do not exist
+// on the system are skipped like copyToRepo does.
+func validateSystemFiles(sys dotf.SysOpsProvider, cfg dotf.Config, jobs int) error {
+	fileFailures := make([][]string, len(cfg.TrackedFiles))
+
+	err := forEachParallel(jobs, len(cfg.TrackedFiles), func(i int) error {
+		tf := cfg.TrackedFiles[i]
+		validators := matchingValidators(cfg.Validators, tf.PathInRepo)
+
+		if len(validators) == 0 || !sys.PathExists(tf.PathOnSystem) {
+			return nil
+		}
+
+		content, err := sys.ReadFile(tf.PathOnSystem)
+
+		if err != nil {
+			return fmt.Errorf("could not read %s: %v", tf.PathOnSystem, err)
+		}
+
+		fileFailures[i], err = runValidators(sys, validators, tf.PathOnSystem, content)
+
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	failures := joinFailures(fileFailures)
+
+	if len(failures) > 0 {
+		return fmt.Errorf("invalid tracked files, nothing was copied to the repo:\n%s", strings.Join(failures, ""))
+	}
+
+	return nil
+}
+
+// validateRepoFiles runs the validators of the config on the given contents
+// of tracked files read from the repo, up to jobs files at the same time.
+func validateRepoFiles(sys dotf.SysOpsProvider, cfg dotf.Config, files []deployedFile, jobs int) error {
+	fileFailures := make([][]string, len(files))
+
+	err := forEachParallel(jobs, len(files), func(i int) error {
+		file := files[i]
+		validators := matchingValidators(cfg.Validators, file.tf.PathInRepo)
+
+		if len(validators) == 0 {
+			return nil
+		}
+
+		var err error
+		fileFailures[i], err = runValidators(sys, validators, strings.TrimPrefix(file.tf.PathInRepo, "/"), file.content)
+
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	failures := joinFailures(fileFailures)
+
+	if len(failures) > 0 {
+		return fmt.Errorf("invalid tracked files in the repo, nothing was deployed:\n%s", strings.Join(failures, ""))
+	}
+
+	return nil
+}
+
+// joinFailures joins the validation failures of all files in the order of the
+// files.
+func joinFailures(fileFailures [][]string) []string {
+	var failures []string
+
+	for _, f := range fileFailures {
+		failures = append(failures, f...)
+	}
+
+	return failures
+}
+
+// matchingValidators returns the validators whose glob matches the path in
+// the repo or the name of a tracked file.
+func matchingValidators(validators []dotf.Validator, pathInRepo string) []dotf.Validator {
+	var matching []dotf.Validator
+
+	file := strings.TrimPrefix(pathInRepo, "/")
+
+	for _, v := range validators {
+		pattern := strings.TrimPrefix(v.Match, "/")
+
+		matchedPath, _ := path.Match(pattern, file)
+		matchedName, _ := path.Match(pattern, path.Base(file))
+
+		if matchedPath || matchedName {
+			matching = append(matching, v)
+		}
+	}
+
+	return matching
+}
+
+// runValidators validates content with every validator and returns a
+// description of each failed validation. An error is only returned if a
+// validator could not be run.
+func runValidators(sys dotf.SysOpsProvider, validators []dotf.Validator, name string, content []byte) ([]string, error) {
+	var failures []string
+
+	for _, v := range validators {
+		var (
+			label   string
+			message string
+		)
+
+		switch {
+		case v.Format != "":
+			if v.Format != dotf.FormatJSON && v.Format != dotf.FormatYAML && v.Format != dotf.FormatTOML {
+				return nil, fmt.Errorf("unknown format %s in validator for %s", v.Format, v.Match)
+			}
+
+			label = v.Format
+			err := parseFormat(v.Format, content)
+
+			if err != nil {
+				message = err.Error()
+			}
+		case v.Command != "":
+			label = v.Command
+			output, exitCode, err := sys.RunCommand(dotf.Command{Line: v.Command, Stdin: content})
+
+			if err != nil {
+				return nil, fmt.Errorf("could not validate %s: %v", name, err)
+			}
+
+			if exitCode != 0 {
+				message = fmt.Sprintf("exit code %d\n%s", exitCode, strings.TrimRight(string(output), "\n"))
+			}
+		default:
+			return nil, fmt.Errorf("validator for %s needs a format or a command", v.Match)
+		}
+
+		if message != "" {
+			failures = append(failures, fmt.Sprintf(
+				"  %s (%s): %s\n",
+				name, label, strings.ReplaceAll(strings.TrimRight(message, "\n"), "\n", "\n    "),
+			))
+		}
+	}
+
+	return failures, nil
+}
+
+// parseFormat parses content with the built-in parser of format and returns
+// the syntax error if there is one.
+func parseFormat(format string, content []byte) error {
+	var value interface{}
+
+	switch format {
+	case dotf.FormatJSON:
+		return json.Unmarshal(content, &value)
+	case dotf.FormatYAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+
+		for {
+			err := decoder.Decode(&value)
+
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		var table map[string]interface{}
+		_, err := toml.Decode(string(content), &table)
+
+		return err
+	}
+}
@@ -0,0 +1,146 @@
+package commands_test
+
+import (
+	"strings"
+	"testing"
+
+	"bakku.dev/dotf"
+	"bakku.dev/dotf/commands"
+	"bakku.dev/dotf/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+func TestPush_ShouldNotCopyFilesRejectedByValidators(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.config/Code/User/settings.json", PathOnSystem: "/home/.config/Code/User/settings.json"},
+			{PathInRepo: "/.config/alacritty/alacritty.yml", PathOnSystem: "/home/.config/alacritty/alacritty.yml"},
+			{PathInRepo: "/.bashrc", PathOnSystem: "/home/.bashrc"},
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+		},
+		Validators: []dotf.Validator{
+			{Match: "*.json", Format: dotf.FormatJSON},
+			{Match: "*.yml", Format: dotf.FormatYAML},
+			{Match: "/.bashrc", Command: "bash -n"},
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(0, nil)
+	m.EXPECT().PathExists("/home/.config/Code/User/settings.json").Return(true)
+	m.EXPECT().ReadFile("/home/.config/Code/User/settings.json").Return([]byte(`{"editor.fontSize": 14,}`), nil)
+	m.EXPECT().PathExists("/home/.config/alacritty/alacritty.yml").Return(true)
+	m.EXPECT().ReadFile("/home/.config/alacritty/alacritty.yml").Return([]byte("font:\n  size: 11\n"), nil)
+	m.EXPECT().PathExists("/home/.bashrc").Return(true)
+	m.EXPECT().ReadFile("/home/.bashrc").Return([]byte("if true; then\n"), nil)
+	m.EXPECT().
+		RunCommand(dotf.Command{Line: "bash -n", Stdin: []byte("if true; then\n")}).
+		Return([]byte("bash: line 2: syntax error: unexpected end of file\n"), 2, nil)
+
+	err := commands.Push(m, commands.PushOptions{})
+
+	expected := "push: invalid tracked files, nothing was copied to the repo:\n" +
+		"  /home/.config/Code/User/settings.json (json): invalid character '}' looking for beginning of object key string\n" +
+		"  /home/.bashrc (bash -n): exit code 2\n" +
+		"    bash: line 2: syntax error: unexpected end of file\n"
+
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected err to be\n%s\ngot\n%v", expected, err)
+	}
+}
+
+func TestPull_ShouldNotDeployFilesRejectedByValidators(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+			{PathInRepo: "/.config/starship.toml", PathOnSystem: "/home/.config/starship.toml"},
+		},
+		Validators: []dotf.Validator{
+			{Match: "*.toml", Format: dotf.FormatTOML},
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().DirtyFiles("/home/repo").Return(nil, nil)
+	m.EXPECT().UpdateRepo("/home/repo", dotf.GitConfig{}).Return(nil)
+	expectRepoReader(ctrl, m, "HEAD", map[string]string{
+		"/.vimrc":                "set number",
+		"/.config/starship.toml": "[character]\nsuccess_symbol = \"[>](bold green)\"\n[character]\n",
+	})
+
+	err := commands.Pull(m, commands.PullOptions{})
+
+	if err == nil || !strings.HasPrefix(err.Error(), "pull: invalid tracked files in the repo, nothing was deployed:\n  .config/starship.toml (toml): ") {
+		t.Fatalf("Expected err to report the invalid toml file, got %v", err)
+	}
+}
+
+func TestPull_ShouldDeployFilesAcceptedByValidators(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.tmux.conf", PathOnSystem: "/home/.tmux.conf"},
+		},
+		Validators: []dotf.Validator{
+			{Match: ".tmux.conf", Command: "tmux -f /dev/stdin start-server"},
+		},
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().DirtyFiles("/home/repo").Return(nil, nil)
+	m.EXPECT().UpdateRepo("/home/repo", dotf.GitConfig{}).Return(nil)
+	expectRepoReader(ctrl, m, "HEAD", map[string]string{"/.tmux.conf": "set -g mouse on\n"})
+	m.EXPECT().
+		RunCommand(dotf.Command{Line: "tmux -f /dev/stdin start-server", Stdin: []byte("set -g mouse on\n")}).
+		Return(nil, 0, nil)
+	m.EXPECT().WriteFile("/home/.tmux.conf", []byte("set -g mouse on\n")).Return(nil)
+
+	expectDeployedRevision(m, cfg, "abc123")
+
+	err := commands.Pull(m, commands.PullOptions{})
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
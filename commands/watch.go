@@ -0,0 +1,266 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"bakku.dev/dotf"
+)
+
+// DefaultWatchDebounce is how long watch waits for further changes before it
+// commits.
+const DefaultWatchDebounce = 2 * time.Second
+
+// WatchOptions configures the watch command.
+type WatchOptions struct {
+	// Debounce is how long no tracked file may change before the changes are
+	// committed.
+	Debounce time.Duration
+	// PushInterval pushes the committed changes regularly. If it is zero the
+	// changes are only committed and pushed with the next push.
+	PushInterval time.Duration
+	// Wait is how long to wait for another dotf process to release the lock
+	// before committing or pushing.
+	Wait time.Duration
+}
+
+// Watch commits tracked files whenever they change on the system until dotf
+// is asked to shut down. Pending changes are committed before it returns. The
+// dotf lock is only held while committing or pushing, changes are retried
+// after the next debounce if another dotf holds it.
+func Watch(sys dotf.SysOpsProvider, opts WatchOptions) error {
+	dotfilePath, err := getDotfConfigPath(sys)
+
+	if err != nil {
+		return fmt.Errorf("watch: %v", err)
+	}
+
+	return watchDotfiles(sys, dotfilePath, opts)
+}
+
+func watchDotfiles(sys dotf.SysOpsProvider, dotfilePath string, opts WatchOptions) error {
+	cfg, err := readConfig(sys, dotfilePath)
+
+	if err != nil {
+		return fmt.Errorf("watch: %v", err)
+	}
+
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultWatchDebounce
+	}
+
+	var paths []string
+
+	for _, tf := range cfg.TrackedFiles {
+		paths = append(paths, tf.PathOnSystem)
+	}
+
+	watcher, err := sys.WatchFiles(paths)
+
+	if err != nil {
+		return fmt.Errorf("watch: %v", err)
+	}
+
+	defer watcher.Close()
+
+	shutdown := sys.ShutdownRequested()
+
+	var push <-chan time.Time
+
+	if opts.PushInterval > 0 {
+		ticker := time.NewTicker(opts.PushInterval)
+		defer ticker.Stop()
+
+		push = ticker.C
+	}
+
+	sys.Log(fmt.Sprintf("watching %d tracked file(s), stop with Ctrl+C\n", len(paths)))
+
+	pending := make(map[string]bool)
+	// unpushed are the tracked files committed since the last push, they are
+	// passed to the postPush hook once pushed.
+	var unpushed []dotf.TrackedFile
+
+	debounce := time.NewTimer(opts.Debounce)
+	debounce.Stop()
+
+	for {
+		select {
+		case path := <-watcher.Events():
+			pending[path] = true
+
+			if !debounce.Stop() {
+				select {
+				case <-debounce.C:
+				default:
+				}
+			}
+
+			debounce.Reset(opts.Debounce)
+		case err := <-watcher.Errors():
+			sys.Warn(fmt.Sprintf("watch: %v\n", err))
+		case <-debounce.C:
+			if len(pending) == 0 {
+				continue
+			}
+
+			if runWatchStepLocked(sys, opts.Wait, func() { unpushed = commitWatchedChanges(sys, cfg, pending, unpushed) }) {
+				pending = make(map[string]bool)
+			} else {
+				debounce.Reset(opts.Debounce)
+			}
+		case <-push:
+			runWatchStepLocked(sys, opts.Wait, func() { unpushed = pushWatchedChanges(sys, cfg, unpushed) })
+		case <-shutdown:
+			debounce.Stop()
+
+			if len(pending) > 0 || opts.PushInterval > 0 {
+				runWatchStepLocked(sys, opts.Wait, func() {
+					unpushed = commitWatchedChanges(sys, cfg, pending, unpushed)
+
+					if opts.PushInterval > 0 {
+						pushWatchedChanges(sys, cfg, unpushed)
+					}
+				})
+			}
+
+			sys.Log("stopped watching\n")
+
+			return nil
+		}
+	}
+}
+
+// runWatchStepLocked runs step while holding the dotf lock. It returns false
+// if the lock could not be taken.
+func runWatchStepLocked(sys dotf.SysOpsProvider, wait time.Duration, step func()) bool {
+	release, err := Lock(sys, LockOptions{Wait: wait})
+
+	if err != nil {
+		sys.Warn(fmt.Sprintf("watch: %v\n", err))
+
+		return false
+	}
+
+	defer release()
+
+	step()
+
+	return true
+}
+
+// commitWatchedChanges copies the changed tracked files into the repo and
+// commits them with a generated message after the prePush hook. It returns
+// unpushed extended by the committed files, the postPush hook only runs once
+// they are pushed. Errors are logged so watching goes on, the files are picked
+// up again by their next change.
+func commitWatchedChanges(sys dotf.SysOpsProvider, cfg dotf.Config, pending map[string]bool, unpushed []dotf.TrackedFile) []dotf.TrackedFile {
+	if len(pending) == 0 {
+		return unpushed
+	}
+
+	changed := cfg
+	changed.TrackedFiles = nil
+
+	for _, tf := range cfg.TrackedFiles {
+		if pending[tf.PathOnSystem] {
+			changed.TrackedFiles = append(changed.TrackedFiles, tf)
+		}
+	}
+
+	if len(changed.TrackedFiles) == 0 {
+		return unpushed
+	}
+
+	err := runPreHook(sys, cfg, operationPush, hookPrePush, cfg.Hooks.PrePush)
+
+	if err != nil {
+		sys.Warn(fmt.Sprintf("watch: %v\n", err))
+
+		return unpushed
+	}
+
+	files, err := copyToRepo(sys, changed, DefaultJobs)
+
+	if err != nil {
+		sys.Warn(fmt.Sprintf("watch: %v\n", err))
+
+		return unpushed
+	}
+
+	changes, err := commitToRepo(sys, changed, files, "")
+
+	if err != nil {
+		sys.Warn(fmt.Sprintf("watch: %v\n", err))
+
+		return unpushed
+	}
+
+	if changes.Empty() {
+		return unpushed
+	}
+
+	committed := append(append([]string{}, changes.Added...), changes.Modified...)
+	sort.Strings(committed)
+
+	sys.Log(fmt.Sprintf("committed %s\n", strings.Join(committed, ", ")))
+
+	for _, tf := range committedFiles(cfg, changes) {
+		if !containsTrackedFile(unpushed, tf) {
+			unpushed = append(unpushed, tf)
+		}
+	}
+
+	return unpushed
+}
+
+// pushWatchedChanges pushes the commits created while watching, between the
+// prePush and postPush hooks. The hooks are skipped if nothing is queued. It
+// returns the tracked files which are still unpushed.
+func pushWatchedChanges(sys dotf.SysOpsProvider, cfg dotf.Config, unpushed []dotf.TrackedFile) []dotf.TrackedFile {
+	count, err := sys.UnpushedCommits(cfg.Repo, cfg.Git)
+
+	if err != nil {
+		sys.Warn(fmt.Sprintf("watch: %v\n", err))
+
+		return unpushed
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	err = runPreHook(sys, cfg, operationPush, hookPrePush, cfg.Hooks.PrePush)
+
+	if err != nil {
+		sys.Warn(fmt.Sprintf("watch: %v\n", err))
+
+		return unpushed
+	}
+
+	sys.Log(fmt.Sprintf("pushing %d queued commit(s)\n", count))
+
+	err = sys.PushRepo(cfg.Repo, cfg.Git)
+
+	if err != nil {
+		sys.Warn(fmt.Sprintf("watch: %v\n", err))
+
+		return unpushed
+	}
+
+	runPostHook(sys, cfg, operationPush, hookPostPush, cfg.Hooks.PostPush, unpushed)
+
+	return nil
+}
+
+func containsTrackedFile(files []dotf.TrackedFile, file dotf.TrackedFile) bool {
+	for _, tf := range files {
+		if tf.PathOnSystem == file.PathOnSystem {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,297 @@
+package commands_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"bakku.dev/dotf"
+	"bakku.dev/dotf/commands"
+	"bakku.dev/dotf/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+// expectWatch sets up reading the config and watching its tracked files. It
+// returns the channels which feed file events and the shutdown signal.
+func expectWatch(ctrl *gomock.Controller, m *mocks.MockSysOpsProvider, cfg dotf.Config) (chan string, chan struct{}) {
+	watcher := mocks.NewMockFileWatcher(ctrl)
+	events := make(chan string)
+	shutdown := make(chan struct{})
+
+	var paths []string
+
+	for _, tf := range cfg.TrackedFiles {
+		paths = append(paths, tf.PathOnSystem)
+	}
+
+	m.EXPECT().GetEnvVar(gomock.Eq("HOME")).Return("/home/")
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home//.dotf").Return("/home/.dotf")
+	m.EXPECT().PathExists(gomock.Eq("/home/.dotf")).Return(true)
+	m.EXPECT().ReadFile(gomock.Eq("/home/.dotf")).Return([]byte("ABC"), nil)
+	m.EXPECT().
+		DeserializeConfig(gomock.Eq([]byte("ABC")), gomock.AssignableToTypeOf(&dotf.Config{})).
+		SetArg(1, cfg).
+		Return(nil)
+	m.EXPECT().WatchFiles(paths).Return(watcher, nil)
+	m.EXPECT().ShutdownRequested().Return(shutdown)
+	m.EXPECT().Log("watching 2 tracked file(s), stop with Ctrl+C\n")
+
+	watcher.EXPECT().Events().Return(events).AnyTimes()
+	watcher.EXPECT().Errors().Return(make(chan error)).AnyTimes()
+	watcher.EXPECT().Close().Return(nil)
+
+	return events, shutdown
+}
+
+// expectWatchCommit sets up copying and committing .vimrc.
+func expectWatchCommit(ctrl *gomock.Controller, m *mocks.MockSysOpsProvider, committed chan struct{}) {
+	m.EXPECT().GetPathSep().Return("/")
+	m.EXPECT().CleanPath("/home/repo//.vimrc").Return("/home/repo/.vimrc")
+	m.EXPECT().CopyFile("/home/.vimrc", "/home/repo/.vimrc").Return(nil)
+	m.EXPECT().RepoChanges("/home/repo", []string{"/.vimrc"}).Return(dotf.FileChanges{Modified: []string{".vimrc"}}, nil)
+	expectSecretScan(ctrl, m)
+	m.EXPECT().GetHostname().Return("laptop", nil)
+	m.EXPECT().Now().Return(lockTime)
+	m.EXPECT().
+		CommitRepo("/home/repo", "Update dotfiles from laptop at 2020-11-08 10:30:00\n\nmodified: .vimrc", []string{"/.vimrc"}, dotf.GitConfig{}).
+		Return(nil)
+	m.EXPECT().Log("committed .vimrc\n").Do(func(string) { close(committed) })
+}
+
+func TestWatch_ShouldCommitDebouncedChangesAndPushOnShutdown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+			{PathInRepo: "/.bashrc", PathOnSystem: "/home/.bashrc"},
+		},
+	}
+
+	events, shutdown := expectWatch(ctrl, m, cfg)
+	committed := make(chan struct{})
+
+	expectLock(m, 2)
+	expectWatchCommit(ctrl, m, committed)
+	m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(1, nil)
+	m.EXPECT().Log("pushing 1 queued commit(s)\n")
+	m.EXPECT().PushRepo("/home/repo", dotf.GitConfig{}).Return(nil)
+	m.EXPECT().Log("stopped watching\n")
+
+	done := make(chan error)
+
+	go func() {
+		done <- commands.Watch(m, commands.WatchOptions{Debounce: 20 * time.Millisecond, PushInterval: time.Hour})
+	}()
+
+	events <- "/home/.vimrc"
+	events <- "/home/.vimrc"
+
+	select {
+	case <-committed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected changes to be committed after the debounce")
+	}
+
+	close(shutdown)
+
+	err := <-done
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestWatch_ShouldFlushPendingChangesOnShutdown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+			{PathInRepo: "/.bashrc", PathOnSystem: "/home/.bashrc"},
+		},
+	}
+
+	events, shutdown := expectWatch(ctrl, m, cfg)
+
+	expectLock(m, 1)
+	expectWatchCommit(ctrl, m, make(chan struct{}))
+	m.EXPECT().Log("stopped watching\n")
+
+	done := make(chan error)
+
+	go func() {
+		done <- commands.Watch(m, commands.WatchOptions{Debounce: time.Hour})
+	}()
+
+	events <- "/home/.vimrc"
+	close(shutdown)
+
+	err := <-done
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestWatch_ShouldRunPostPushHookOnlyAfterPushing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+			{PathInRepo: "/.bashrc", PathOnSystem: "/home/.bashrc"},
+		},
+		Hooks: dotf.Hooks{
+			PrePush:  "make lint",
+			PostPush: "notify-send dotf pushed",
+		},
+	}
+
+	events, shutdown := expectWatch(ctrl, m, cfg)
+	committed := make(chan struct{})
+
+	var hooks []string
+
+	expectLock(m, 2)
+	expectWatchCommit(ctrl, m, committed)
+	m.EXPECT().Log("running prePush hook: make lint\n").Times(2)
+	m.EXPECT().Log("running postPush hook: notify-send dotf pushed\n")
+	m.EXPECT().
+		RunCommand(gomock.Any()).
+		DoAndReturn(func(command dotf.Command) ([]byte, int, error) {
+			hooks = append(hooks, command.Env[1]+" "+command.Env[3])
+
+			return nil, 0, nil
+		}).
+		Times(3)
+	m.EXPECT().UnpushedCommits("/home/repo", dotf.GitConfig{}).Return(1, nil)
+	m.EXPECT().Log("pushing 1 queued commit(s)\n")
+	m.EXPECT().PushRepo("/home/repo", dotf.GitConfig{}).Return(nil)
+	m.EXPECT().Log("stopped watching\n")
+
+	done := make(chan error)
+
+	go func() {
+		done <- commands.Watch(m, commands.WatchOptions{Debounce: 20 * time.Millisecond, PushInterval: time.Hour})
+	}()
+
+	events <- "/home/.vimrc"
+
+	select {
+	case <-committed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected changes to be committed after the debounce")
+	}
+
+	close(shutdown)
+
+	err := <-done
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+
+	expected := []string{
+		"DOTF_HOOK=prePush DOTF_CHANGED_FILES=",
+		"DOTF_HOOK=prePush DOTF_CHANGED_FILES=",
+		"DOTF_HOOK=postPush DOTF_CHANGED_FILES=/home/.vimrc",
+	}
+
+	if !reflect.DeepEqual(hooks, expected) {
+		t.Fatalf("Expected hooks %v, got %v", expected, hooks)
+	}
+}
+
+func TestWatch_ShouldNotCommitIfPrePushHookVetoes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+			{PathInRepo: "/.bashrc", PathOnSystem: "/home/.bashrc"},
+		},
+		Hooks: dotf.Hooks{
+			PrePush: "make lint",
+		},
+	}
+
+	events, shutdown := expectWatch(ctrl, m, cfg)
+
+	expectLock(m, 1)
+	m.EXPECT().Log("running prePush hook: make lint\n")
+	m.EXPECT().RunCommand(gomock.Any()).Return(nil, 2, nil)
+	m.EXPECT().Warn("watch: hook prePush failed with exit code 2, the push was vetoed\n")
+	m.EXPECT().Log("stopped watching\n")
+
+	done := make(chan error)
+
+	go func() {
+		done <- commands.Watch(m, commands.WatchOptions{Debounce: time.Hour})
+	}()
+
+	events <- "/home/.vimrc"
+	close(shutdown)
+
+	err := <-done
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
+
+func TestWatch_ShouldNotRunPostPushHookWithoutPushing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocks.NewMockSysOpsProvider(ctrl)
+
+	cfg := dotf.Config{
+		Repo: "/home/repo",
+		TrackedFiles: []dotf.TrackedFile{
+			{PathInRepo: "/.vimrc", PathOnSystem: "/home/.vimrc"},
+			{PathInRepo: "/.bashrc", PathOnSystem: "/home/.bashrc"},
+		},
+		Hooks: dotf.Hooks{
+			PostPush: "notify-send dotf pushed",
+		},
+	}
+
+	events, shutdown := expectWatch(ctrl, m, cfg)
+
+	expectLock(m, 1)
+	expectWatchCommit(ctrl, m, make(chan struct{}))
+	m.EXPECT().Log("stopped watching\n")
+
+	done := make(chan error)
+
+	go func() {
+		done <- commands.Watch(m, commands.WatchOptions{Debounce: time.Hour})
+	}()
+
+	events <- "/home/.vimrc"
+	close(shutdown)
+
+	err := <-done
+
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got %v", err)
+	}
+}
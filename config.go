@@ -1,14 +1,93 @@
 package dotf
 
-// TrackedFile represents a file that is being tracked by dotf.
+// TrackedFile represents a file that is being tracked by dotf. OnChange is a
+// shell command which runs after pull changed the content of the file.
 type TrackedFile struct {
 	PathInRepo   string `json:"pathInRepo"`
 	PathOnSystem string `json:"pathOnSystem"`
+	OnChange     string `json:"onChange,omitempty"`
+}
+
+// Hooks are shell commands which run in the repo before and after pull and
+// push. A pre hook which exits non-zero vetoes the command, post hooks only
+// run if the command succeeded. Hooks get the operation, the repo and the
+// changed files as DOTF_* environment variables and as JSON on stdin.
+type Hooks struct {
+	PrePull  string `json:"prePull,omitempty"`
+	PostPull string `json:"postPull,omitempty"`
+	PrePush  string `json:"prePush,omitempty"`
+	PostPush string `json:"postPush,omitempty"`
+}
+
+// GitAuth configures how dotf authenticates against the remote. Method is one
+// of "ssh-key", "ssh-agent", "http-basic" or "http-token". Secrets are never
+// stored in the config, they are read from the environment or prompted for.
+type GitAuth struct {
+	Method  string `json:"method,omitempty"`
+	User    string `json:"user,omitempty"`
+	KeyFile string `json:"keyFile,omitempty"`
+}
+
+// Pull strategies which decide how pull handles a local branch that has
+// diverged from the remote branch.
+const (
+	PullFastForward = "ff-only"
+	PullMerge       = "merge"
+	PullRebase      = "rebase"
+	PullReset       = "reset"
+)
+
+// GitConfig contains the settings dotf uses for git operations on the repo.
+// SigningKey is the path to an OpenPGP private key which signs every commit
+// dotf creates. TrustedKeys are paths to public keys of other committers whose
+// signatures are accepted when verifying commits.
+type GitConfig struct {
+	Remote       string   `json:"remote,omitempty"`
+	Branch       string   `json:"branch,omitempty"`
+	AuthorName   string   `json:"authorName,omitempty"`
+	AuthorEmail  string   `json:"authorEmail,omitempty"`
+	PullStrategy string   `json:"pullStrategy,omitempty"`
+	Auth         GitAuth  `json:"auth"`
+	SigningKey   string   `json:"signingKey,omitempty"`
+	TrustedKeys  []string `json:"trustedKeys,omitempty"`
+}
+
+// SecretAllowRule suppresses findings of the secret scanner. Only the set
+// fields are compared: File is a glob for the path in the repo, Rule the name
+// of the scanner rule and Pattern a regular expression for the flagged line.
+type SecretAllowRule struct {
+	File    string `json:"file,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// Built-in formats of a Validator.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// Validator checks the syntax of tracked files before push copies them into
+// the repo and before pull deploys them. Match is a glob compared with the
+// path in the repo and with the file name, e.g. "*.json". Format selects a
+// built-in parser, otherwise Command is run by the shell with the content of
+// the file on stdin and fails the validation if it exits non-zero.
+type Validator struct {
+	Match   string `json:"match"`
+	Format  string `json:"format,omitempty"`
+	Command string `json:"command,omitempty"`
 }
 
 // Config contains all attributes to parse the dotf config file.
 type Config struct {
-	Repo          string        `json:"repo"`
-	CreateBackups bool          `json:"createBackups"`
-	TrackedFiles  []TrackedFile `json:"trackedFiles"`
+	Repo             string            `json:"repo"`
+	CreateBackups    bool              `json:"createBackups"`
+	TrackedFiles     []TrackedFile     `json:"trackedFiles"`
+	CommitTemplate   string            `json:"commitTemplate,omitempty"`
+	Git              GitConfig         `json:"git"`
+	DeployedRevision string            `json:"deployedRevision,omitempty"`
+	SecretAllowList  []SecretAllowRule `json:"secretAllowList,omitempty"`
+	Validators       []Validator       `json:"validators,omitempty"`
+	Hooks            Hooks             `json:"hooks"`
 }
@@ -3,16 +3,20 @@ module bakku.dev/dotf
 go 1.15
 
 require (
+	github.com/BurntSushi/toml v0.4.1
 	github.com/Microsoft/go-winio v0.4.15 // indirect
 	github.com/cpuguy83/go-md2man/v2 v2.0.0 // indirect
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/go-git/go-git/v5 v5.2.0
 	github.com/golang/mock v1.4.4
 	github.com/imdario/mergo v0.3.11 // indirect
 	github.com/mattn/go-runewidth v0.0.9 // indirect
 	github.com/olekukonko/tablewriter v0.0.4
+	github.com/sergi/go-diff v1.1.0
 	github.com/urfave/cli/v2 v2.2.0
 	github.com/xanzy/ssh-agent v0.3.0 // indirect
-	golang.org/x/crypto v0.0.0-20201016220609-9e8e0b390897 // indirect
+	golang.org/x/crypto v0.0.0-20201016220609-9e8e0b390897
 	golang.org/x/net v0.0.0-20201031054903-ff519b6c9102 // indirect
 	golang.org/x/sys v0.0.0-20201101102859-da207088b7d1 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -8,8 +8,112 @@ import (
 	dotf "bakku.dev/dotf"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
+	time "time"
 )
 
+// MockRepoReader is a mock of RepoReader interface
+type MockRepoReader struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepoReaderMockRecorder
+}
+
+// MockRepoReaderMockRecorder is the mock recorder for MockRepoReader
+type MockRepoReaderMockRecorder struct {
+	mock *MockRepoReader
+}
+
+// NewMockRepoReader creates a new mock instance
+func NewMockRepoReader(ctrl *gomock.Controller) *MockRepoReader {
+	mock := &MockRepoReader{ctrl: ctrl}
+	mock.recorder = &MockRepoReaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockRepoReader) EXPECT() *MockRepoReaderMockRecorder {
+	return m.recorder
+}
+
+// ReadFile mocks base method
+func (m *MockRepoReader) ReadFile(pathInRepo string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadFile", pathInRepo)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadFile indicates an expected call of ReadFile
+func (mr *MockRepoReaderMockRecorder) ReadFile(pathInRepo interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadFile", reflect.TypeOf((*MockRepoReader)(nil).ReadFile), pathInRepo)
+}
+
+// MockFileWatcher is a mock of FileWatcher interface
+type MockFileWatcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileWatcherMockRecorder
+}
+
+// MockFileWatcherMockRecorder is the mock recorder for MockFileWatcher
+type MockFileWatcherMockRecorder struct {
+	mock *MockFileWatcher
+}
+
+// NewMockFileWatcher creates a new mock instance
+func NewMockFileWatcher(ctrl *gomock.Controller) *MockFileWatcher {
+	mock := &MockFileWatcher{ctrl: ctrl}
+	mock.recorder = &MockFileWatcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockFileWatcher) EXPECT() *MockFileWatcherMockRecorder {
+	return m.recorder
+}
+
+// Events mocks base method
+func (m *MockFileWatcher) Events() <-chan string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Events")
+	ret0, _ := ret[0].(<-chan string)
+	return ret0
+}
+
+// Events indicates an expected call of Events
+func (mr *MockFileWatcherMockRecorder) Events() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Events", reflect.TypeOf((*MockFileWatcher)(nil).Events))
+}
+
+// Errors mocks base method
+func (m *MockFileWatcher) Errors() <-chan error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Errors")
+	ret0, _ := ret[0].(<-chan error)
+	return ret0
+}
+
+// Errors indicates an expected call of Errors
+func (mr *MockFileWatcherMockRecorder) Errors() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Errors", reflect.TypeOf((*MockFileWatcher)(nil).Errors))
+}
+
+// Close mocks base method
+func (m *MockFileWatcher) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close
+func (mr *MockFileWatcherMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockFileWatcher)(nil).Close))
+}
+
 // MockSysOpsProvider is a mock of SysOpsProvider interface
 type MockSysOpsProvider struct {
 	ctrl     *gomock.Controller
@@ -61,6 +165,90 @@ func (mr *MockSysOpsProviderMockRecorder) GetPathSep() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPathSep", reflect.TypeOf((*MockSysOpsProvider)(nil).GetPathSep))
 }
 
+// GetHostname mocks base method
+func (m *MockSysOpsProvider) GetHostname() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHostname")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHostname indicates an expected call of GetHostname
+func (mr *MockSysOpsProviderMockRecorder) GetHostname() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHostname", reflect.TypeOf((*MockSysOpsProvider)(nil).GetHostname))
+}
+
+// Executable mocks base method
+func (m *MockSysOpsProvider) Executable() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Executable")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Executable indicates an expected call of Executable
+func (mr *MockSysOpsProviderMockRecorder) Executable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Executable", reflect.TypeOf((*MockSysOpsProvider)(nil).Executable))
+}
+
+// Now mocks base method
+func (m *MockSysOpsProvider) Now() time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Now")
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// Now indicates an expected call of Now
+func (mr *MockSysOpsProviderMockRecorder) Now() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Now", reflect.TypeOf((*MockSysOpsProvider)(nil).Now))
+}
+
+// Sleep mocks base method
+func (m *MockSysOpsProvider) Sleep(d time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Sleep", d)
+}
+
+// Sleep indicates an expected call of Sleep
+func (mr *MockSysOpsProviderMockRecorder) Sleep(d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sleep", reflect.TypeOf((*MockSysOpsProvider)(nil).Sleep), d)
+}
+
+// GetPid mocks base method
+func (m *MockSysOpsProvider) GetPid() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPid")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GetPid indicates an expected call of GetPid
+func (mr *MockSysOpsProviderMockRecorder) GetPid() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPid", reflect.TypeOf((*MockSysOpsProvider)(nil).GetPid))
+}
+
+// ProcessExists mocks base method
+func (m *MockSysOpsProvider) ProcessExists(pid int) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessExists", pid)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// ProcessExists indicates an expected call of ProcessExists
+func (mr *MockSysOpsProviderMockRecorder) ProcessExists(pid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessExists", reflect.TypeOf((*MockSysOpsProvider)(nil).ProcessExists), pid)
+}
+
 // CleanPath mocks base method
 func (m *MockSysOpsProvider) CleanPath(path string) string {
 	m.ctrl.T.Helper()
@@ -116,6 +304,18 @@ func (mr *MockSysOpsProviderMockRecorder) Log(message interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Log", reflect.TypeOf((*MockSysOpsProvider)(nil).Log), message)
 }
 
+// Warn mocks base method
+func (m *MockSysOpsProvider) Warn(message string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Warn", message)
+}
+
+// Warn indicates an expected call of Warn
+func (mr *MockSysOpsProviderMockRecorder) Warn(message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warn", reflect.TypeOf((*MockSysOpsProvider)(nil).Warn), message)
+}
+
 // ReadLine mocks base method
 func (m *MockSysOpsProvider) ReadLine() (string, error) {
 	m.ctrl.T.Helper()
@@ -131,6 +331,20 @@ func (mr *MockSysOpsProviderMockRecorder) ReadLine() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadLine", reflect.TypeOf((*MockSysOpsProvider)(nil).ReadLine))
 }
 
+// ShutdownRequested mocks base method
+func (m *MockSysOpsProvider) ShutdownRequested() <-chan struct{} {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShutdownRequested")
+	ret0, _ := ret[0].(<-chan struct{})
+	return ret0
+}
+
+// ShutdownRequested indicates an expected call of ShutdownRequested
+func (mr *MockSysOpsProviderMockRecorder) ShutdownRequested() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShutdownRequested", reflect.TypeOf((*MockSysOpsProvider)(nil).ShutdownRequested))
+}
+
 // SerializeConfig mocks base method
 func (m *MockSysOpsProvider) SerializeConfig(c dotf.Config) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -189,6 +403,20 @@ func (mr *MockSysOpsProviderMockRecorder) ReadFile(path interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadFile", reflect.TypeOf((*MockSysOpsProvider)(nil).ReadFile), path)
 }
 
+// ReplaceFile mocks base method
+func (m *MockSysOpsProvider) ReplaceFile(path string, expected, content []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceFile", path, expected, content)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceFile indicates an expected call of ReplaceFile
+func (mr *MockSysOpsProviderMockRecorder) ReplaceFile(path, expected, content interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceFile", reflect.TypeOf((*MockSysOpsProvider)(nil).ReplaceFile), path, expected, content)
+}
+
 // CopyFile mocks base method
 func (m *MockSysOpsProvider) CopyFile(src, dest string) error {
 	m.ctrl.T.Helper()
@@ -203,30 +431,310 @@ func (mr *MockSysOpsProviderMockRecorder) CopyFile(src, dest interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyFile", reflect.TypeOf((*MockSysOpsProvider)(nil).CopyFile), src, dest)
 }
 
+// RemoveFile mocks base method
+func (m *MockSysOpsProvider) RemoveFile(path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveFile", path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveFile indicates an expected call of RemoveFile
+func (mr *MockSysOpsProviderMockRecorder) RemoveFile(path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFile", reflect.TypeOf((*MockSysOpsProvider)(nil).RemoveFile), path)
+}
+
+// CreateLockFile mocks base method
+func (m *MockSysOpsProvider) CreateLockFile(path string, content []byte) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLockFile", path, content)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateLockFile indicates an expected call of CreateLockFile
+func (mr *MockSysOpsProviderMockRecorder) CreateLockFile(path, content interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLockFile", reflect.TypeOf((*MockSysOpsProvider)(nil).CreateLockFile), path, content)
+}
+
+// RemoveLockFile mocks base method
+func (m *MockSysOpsProvider) RemoveLockFile(path string, content []byte) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveLockFile", path, content)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveLockFile indicates an expected call of RemoveLockFile
+func (mr *MockSysOpsProviderMockRecorder) RemoveLockFile(path, content interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveLockFile", reflect.TypeOf((*MockSysOpsProvider)(nil).RemoveLockFile), path, content)
+}
+
+// WatchFiles mocks base method
+func (m *MockSysOpsProvider) WatchFiles(paths []string) (dotf.FileWatcher, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchFiles", paths)
+	ret0, _ := ret[0].(dotf.FileWatcher)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchFiles indicates an expected call of WatchFiles
+func (mr *MockSysOpsProviderMockRecorder) WatchFiles(paths interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchFiles", reflect.TypeOf((*MockSysOpsProvider)(nil).WatchFiles), paths)
+}
+
+// RunCommand mocks base method
+func (m *MockSysOpsProvider) RunCommand(cmd dotf.Command) ([]byte, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunCommand", cmd)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RunCommand indicates an expected call of RunCommand
+func (mr *MockSysOpsProviderMockRecorder) RunCommand(cmd interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunCommand", reflect.TypeOf((*MockSysOpsProvider)(nil).RunCommand), cmd)
+}
+
+// FetchRepo mocks base method
+func (m *MockSysOpsProvider) FetchRepo(path string, gitCfg dotf.GitConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchRepo", path, gitCfg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FetchRepo indicates an expected call of FetchRepo
+func (mr *MockSysOpsProviderMockRecorder) FetchRepo(path, gitCfg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchRepo", reflect.TypeOf((*MockSysOpsProvider)(nil).FetchRepo), path, gitCfg)
+}
+
 // UpdateRepo mocks base method
-func (m *MockSysOpsProvider) UpdateRepo(path string) error {
+func (m *MockSysOpsProvider) UpdateRepo(path string, gitCfg dotf.GitConfig) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateRepo", path)
+	ret := m.ctrl.Call(m, "UpdateRepo", path, gitCfg)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateRepo indicates an expected call of UpdateRepo
-func (mr *MockSysOpsProviderMockRecorder) UpdateRepo(path interface{}) *gomock.Call {
+func (mr *MockSysOpsProviderMockRecorder) UpdateRepo(path, gitCfg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRepo", reflect.TypeOf((*MockSysOpsProvider)(nil).UpdateRepo), path, gitCfg)
+}
+
+// ResolveRevision mocks base method
+func (m *MockSysOpsProvider) ResolveRevision(path, rev string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveRevision", path, rev)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveRevision indicates an expected call of ResolveRevision
+func (mr *MockSysOpsProviderMockRecorder) ResolveRevision(path, rev interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveRevision", reflect.TypeOf((*MockSysOpsProvider)(nil).ResolveRevision), path, rev)
+}
+
+// OpenRepoReader mocks base method
+func (m *MockSysOpsProvider) OpenRepoReader(path, rev string) (dotf.RepoReader, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenRepoReader", path, rev)
+	ret0, _ := ret[0].(dotf.RepoReader)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenRepoReader indicates an expected call of OpenRepoReader
+func (mr *MockSysOpsProviderMockRecorder) OpenRepoReader(path, rev interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRepo", reflect.TypeOf((*MockSysOpsProvider)(nil).UpdateRepo), path)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenRepoReader", reflect.TypeOf((*MockSysOpsProvider)(nil).OpenRepoReader), path, rev)
+}
+
+// FileHistory mocks base method
+func (m *MockSysOpsProvider) FileHistory(path, file string) ([]dotf.Commit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FileHistory", path, file)
+	ret0, _ := ret[0].([]dotf.Commit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FileHistory indicates an expected call of FileHistory
+func (mr *MockSysOpsProviderMockRecorder) FileHistory(path, file interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FileHistory", reflect.TypeOf((*MockSysOpsProvider)(nil).FileHistory), path, file)
+}
+
+// VerifyCommit mocks base method
+func (m *MockSysOpsProvider) VerifyCommit(path, hash string, gitCfg dotf.GitConfig) (dotf.Signature, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyCommit", path, hash, gitCfg)
+	ret0, _ := ret[0].(dotf.Signature)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyCommit indicates an expected call of VerifyCommit
+func (mr *MockSysOpsProviderMockRecorder) VerifyCommit(path, hash, gitCfg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyCommit", reflect.TypeOf((*MockSysOpsProvider)(nil).VerifyCommit), path, hash, gitCfg)
+}
+
+// RepoChanges mocks base method
+func (m *MockSysOpsProvider) RepoChanges(path string, files []string) (dotf.FileChanges, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RepoChanges", path, files)
+	ret0, _ := ret[0].(dotf.FileChanges)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RepoChanges indicates an expected call of RepoChanges
+func (mr *MockSysOpsProviderMockRecorder) RepoChanges(path, files interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepoChanges", reflect.TypeOf((*MockSysOpsProvider)(nil).RepoChanges), path, files)
+}
+
+// DirtyFiles mocks base method
+func (m *MockSysOpsProvider) DirtyFiles(path string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DirtyFiles", path)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DirtyFiles indicates an expected call of DirtyFiles
+func (mr *MockSysOpsProviderMockRecorder) DirtyFiles(path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DirtyFiles", reflect.TypeOf((*MockSysOpsProvider)(nil).DirtyFiles), path)
+}
+
+// DiscardChanges mocks base method
+func (m *MockSysOpsProvider) DiscardChanges(path string, files []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiscardChanges", path, files)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DiscardChanges indicates an expected call of DiscardChanges
+func (mr *MockSysOpsProviderMockRecorder) DiscardChanges(path, files interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscardChanges", reflect.TypeOf((*MockSysOpsProvider)(nil).DiscardChanges), path, files)
 }
 
 // CommitRepo mocks base method
-func (m *MockSysOpsProvider) CommitRepo(path, message string) error {
+func (m *MockSysOpsProvider) CommitRepo(path, message string, files []string, gitCfg dotf.GitConfig) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CommitRepo", path, message)
+	ret := m.ctrl.Call(m, "CommitRepo", path, message, files, gitCfg)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CommitRepo indicates an expected call of CommitRepo
-func (mr *MockSysOpsProviderMockRecorder) CommitRepo(path, message interface{}) *gomock.Call {
+func (mr *MockSysOpsProviderMockRecorder) CommitRepo(path, message, files, gitCfg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitRepo", reflect.TypeOf((*MockSysOpsProvider)(nil).CommitRepo), path, message, files, gitCfg)
+}
+
+// PushRepo mocks base method
+func (m *MockSysOpsProvider) PushRepo(path string, gitCfg dotf.GitConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PushRepo", path, gitCfg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PushRepo indicates an expected call of PushRepo
+func (mr *MockSysOpsProviderMockRecorder) PushRepo(path, gitCfg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PushRepo", reflect.TypeOf((*MockSysOpsProvider)(nil).PushRepo), path, gitCfg)
+}
+
+// CreateTag mocks base method
+func (m *MockSysOpsProvider) CreateTag(path, name, message string, gitCfg dotf.GitConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTag", path, name, message, gitCfg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTag indicates an expected call of CreateTag
+func (mr *MockSysOpsProviderMockRecorder) CreateTag(path, name, message, gitCfg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTag", reflect.TypeOf((*MockSysOpsProvider)(nil).CreateTag), path, name, message, gitCfg)
+}
+
+// PushTag mocks base method
+func (m *MockSysOpsProvider) PushTag(path, name string, gitCfg dotf.GitConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PushTag", path, name, gitCfg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PushTag indicates an expected call of PushTag
+func (mr *MockSysOpsProviderMockRecorder) PushTag(path, name, gitCfg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PushTag", reflect.TypeOf((*MockSysOpsProvider)(nil).PushTag), path, name, gitCfg)
+}
+
+// ListTags mocks base method
+func (m *MockSysOpsProvider) ListTags(path, prefix string) ([]dotf.Tag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTags", path, prefix)
+	ret0, _ := ret[0].([]dotf.Tag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTags indicates an expected call of ListTags
+func (mr *MockSysOpsProviderMockRecorder) ListTags(path, prefix interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTags", reflect.TypeOf((*MockSysOpsProvider)(nil).ListTags), path, prefix)
+}
+
+// UnpushedCommits mocks base method
+func (m *MockSysOpsProvider) UnpushedCommits(path string, gitCfg dotf.GitConfig) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnpushedCommits", path, gitCfg)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnpushedCommits indicates an expected call of UnpushedCommits
+func (mr *MockSysOpsProviderMockRecorder) UnpushedCommits(path, gitCfg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnpushedCommits", reflect.TypeOf((*MockSysOpsProvider)(nil).UnpushedCommits), path, gitCfg)
+}
+
+// ListUnpushedCommits mocks base method
+func (m *MockSysOpsProvider) ListUnpushedCommits(path string, gitCfg dotf.GitConfig) ([]dotf.Commit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUnpushedCommits", path, gitCfg)
+	ret0, _ := ret[0].([]dotf.Commit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUnpushedCommits indicates an expected call of ListUnpushedCommits
+func (mr *MockSysOpsProviderMockRecorder) ListUnpushedCommits(path, gitCfg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitRepo", reflect.TypeOf((*MockSysOpsProvider)(nil).CommitRepo), path, message)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUnpushedCommits", reflect.TypeOf((*MockSysOpsProvider)(nil).ListUnpushedCommits), path, gitCfg)
 }
@@ -1,19 +1,135 @@
 package dotf
 
+import (
+	"errors"
+	"time"
+)
+
+// ErrNothingToCommit is returned by SysOpsProvider.CommitRepo if none of the
+// given files contain changes.
+var ErrNothingToCommit = errors.New("nothing to commit")
+
+// ErrConflict is returned by SysOpsProvider.ReplaceFile if the file was
+// changed by another process since it was read.
+var ErrConflict = errors.New("file was changed by another process")
+
+// ErrNotInRepo is returned by RepoReader.ReadFile if the file does not exist
+// in the state of the repo the reader serves.
+var ErrNotInRepo = errors.New("file does not exist in repo")
+
+// FileChanges lists the tracked files of a repository which differ from its
+// last commit. All paths are relative to the repository root.
+type FileChanges struct {
+	Added    []string
+	Modified []string
+	Deleted  []string
+}
+
+// Empty returns true if no file changed.
+func (fc FileChanges) Empty() bool {
+	return len(fc.Added) == 0 && len(fc.Modified) == 0 && len(fc.Deleted) == 0
+}
+
+// Commit describes a commit of the dotfile repository.
+type Commit struct {
+	Hash    string
+	Author  string
+	When    time.Time
+	Message string
+}
+
+// Signature states of a commit as reported by SysOpsProvider.VerifyCommit.
+const (
+	SignatureNone    = "unsigned"
+	SignatureGood    = "good"
+	SignatureUnknown = "unknown key"
+	SignatureBad     = "bad"
+)
+
+// Signature describes the signature of a commit. Signer is only set for good
+// signatures.
+type Signature struct {
+	State  string
+	Signer string
+}
+
+// Tag describes a tag of the dotfile repository. For lightweight tags When
+// and Message are taken from the tagged commit.
+type Tag struct {
+	Name    string
+	Commit  string
+	When    time.Time
+	Message string
+}
+
+// RepoReader reads tracked files from one state of a repository, either its
+// worktree or a committed revision.
+type RepoReader interface {
+	// ReadFile returns the content of a file given by its path in the repo.
+	ReadFile(pathInRepo string) ([]byte, error)
+}
+
+// Command is a command line which SysOpsProvider.RunCommand runs with the
+// shell of the system. Stdin is passed to its standard input and Env, a list
+// of KEY=value entries, is added to the environment of dotf. If Dir is empty
+// the command runs in the current directory.
+type Command struct {
+	Line  string
+	Dir   string
+	Env   []string
+	Stdin []byte
+}
+
+// FileWatcher reports changes of watched files. Events yields the path of a
+// file each time it is written or replaced.
+type FileWatcher interface {
+	Events() <-chan string
+	Errors() <-chan error
+	Close() error
+}
+
 // SysOpsProvider provides all system operation which dotf needs.
 type SysOpsProvider interface {
 	GetEnvVar(s string) string
 	GetPathSep() string
+	GetHostname() (string, error)
+	Executable() (string, error)
+	Now() time.Time
+	Sleep(d time.Duration)
+	GetPid() int
+	ProcessExists(pid int) bool
 	CleanPath(path string) string
 	PathExists(path string) bool
 	ExpandPath(path string) (string, error)
 	Log(message string)
+	Warn(message string)
 	ReadLine() (string, error)
+	ShutdownRequested() <-chan struct{}
 	SerializeConfig(c Config) ([]byte, error)
 	DeserializeConfig(raw []byte, c *Config) error
 	WriteFile(path string, content []byte) error
 	ReadFile(path string) ([]byte, error)
+	ReplaceFile(path string, expected, content []byte) error
 	CopyFile(src, dest string) error
-	UpdateRepo(path string) error
-	CommitRepo(path, message string) error
+	RemoveFile(path string) error
+	CreateLockFile(path string, content []byte) (bool, error)
+	RemoveLockFile(path string, content []byte) (bool, error)
+	WatchFiles(paths []string) (FileWatcher, error)
+	RunCommand(cmd Command) ([]byte, int, error)
+	FetchRepo(path string, gitCfg GitConfig) error
+	UpdateRepo(path string, gitCfg GitConfig) error
+	ResolveRevision(path, rev string) (string, error)
+	OpenRepoReader(path, rev string) (RepoReader, error)
+	FileHistory(path, file string) ([]Commit, error)
+	VerifyCommit(path, hash string, gitCfg GitConfig) (Signature, error)
+	RepoChanges(path string, files []string) (FileChanges, error)
+	DirtyFiles(path string) ([]string, error)
+	DiscardChanges(path string, files []string) error
+	CommitRepo(path, message string, files []string, gitCfg GitConfig) error
+	PushRepo(path string, gitCfg GitConfig) error
+	CreateTag(path, name, message string, gitCfg GitConfig) error
+	PushTag(path, name string, gitCfg GitConfig) error
+	ListTags(path, prefix string) ([]Tag, error)
+	UnpushedCommits(path string, gitCfg GitConfig) (int, error)
+	ListUnpushedCommits(path string, gitCfg GitConfig) ([]Commit, error)
 }
@@ -0,0 +1,155 @@
+package sysop
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"bakku.dev/dotf"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	cryptossh "golang.org/x/crypto/ssh"
+)
+
+// Environment variables which override the auth settings of the config or
+// provide the secrets for them.
+const (
+	envAuthMethod    = "DOTF_GIT_AUTH"
+	envAuthUser      = "DOTF_GIT_USER"
+	envSSHKey        = "DOTF_GIT_SSH_KEY"
+	envSSHPassphrase = "DOTF_GIT_SSH_PASSPHRASE"
+	envPassword      = "DOTF_GIT_PASSWORD"
+	envToken         = "DOTF_GIT_TOKEN"
+)
+
+const defaultSSHUser = "git"
+
+// authMethod builds the go-git auth method for the given config. The result
+// is cached so secrets are only prompted for once per run. A nil auth method
+// lets go-git pick its defaults.
+func (sop *Provider) authMethod(gitCfg dotf.GitConfig) (transport.AuthMethod, error) {
+	if sop.authResolved {
+		return sop.auth, nil
+	}
+
+	auth, err := sop.buildAuthMethod(gitCfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	sop.auth = auth
+	sop.authResolved = true
+
+	return auth, nil
+}
+
+func (sop *Provider) buildAuthMethod(cfg dotf.GitAuth) (transport.AuthMethod, error) {
+	method := sop.envOr(envAuthMethod, cfg.Method)
+	user := sop.envOr(envAuthUser, cfg.User)
+
+	switch method {
+	case "":
+		return nil, nil
+	case "ssh-key":
+		return sop.sshKeyAuth(user, sop.envOr(envSSHKey, cfg.KeyFile))
+	case "ssh-agent":
+		if user == "" {
+			user = defaultSSHUser
+		}
+
+		auth, err := ssh.NewSSHAgentAuth(user)
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to ssh agent: %v", err)
+		}
+
+		return auth, nil
+	case "http-basic":
+		password := sop.GetEnvVar(envPassword)
+
+		if password == "" {
+			var err error
+
+			sop.Warn(fmt.Sprintf("Password for %s: ", user))
+
+			password, err = sop.ReadLine()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return &http.BasicAuth{Username: user, Password: password}, nil
+	case "http-token":
+		token := sop.GetEnvVar(envToken)
+
+		if token == "" {
+			return nil, fmt.Errorf("auth method http-token requires %s to be set", envToken)
+		}
+
+		if user == "" {
+			return &http.TokenAuth{Token: token}, nil
+		}
+
+		return &http.BasicAuth{Username: user, Password: token}, nil
+	default:
+		return nil, fmt.Errorf("unknown auth method %s", method)
+	}
+}
+
+func (sop *Provider) sshKeyAuth(user, keyFile string) (transport.AuthMethod, error) {
+	if keyFile == "" {
+		return nil, fmt.Errorf("auth method ssh-key requires a key file")
+	}
+
+	if user == "" {
+		user = defaultSSHUser
+	}
+
+	keyFile = sop.expandHome(keyFile)
+
+	pemBytes, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read ssh key %s: %v", keyFile, err)
+	}
+
+	passphrase := sop.GetEnvVar(envSSHPassphrase)
+
+	if passphrase == "" {
+		_, err = cryptossh.ParseRawPrivateKey(pemBytes)
+
+		if _, ok := err.(*cryptossh.PassphraseMissingError); ok {
+			sop.Warn(fmt.Sprintf("Passphrase for %s: ", keyFile))
+
+			passphrase, err = sop.ReadLine()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	auth, err := ssh.NewPublicKeys(user, pemBytes, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("could not load ssh key %s: %v", keyFile, err)
+	}
+
+	return auth, nil
+}
+
+// expandHome replaces a leading ~ in path with the home directory.
+func (sop *Provider) expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		return sop.GetEnvVar("HOME") + path[1:]
+	}
+
+	return path
+}
+
+// envOr returns the value of the environment variable name if it is set and
+// fallback otherwise.
+func (sop *Provider) envOr(name, fallback string) string {
+	if value := sop.GetEnvVar(name); value != "" {
+		return value
+	}
+
+	return fallback
+}
@@ -0,0 +1,214 @@
+package sysop
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"bakku.dev/dotf"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestAuthMethod_ShouldReturnNilWithoutConfiguredMethod(t *testing.T) {
+	op := &Provider{}
+
+	auth, err := op.authMethod(dotf.GitConfig{})
+	if err != nil || auth != nil {
+		t.Fatalf("expected no auth method, got %v, %v", auth, err)
+	}
+}
+
+func TestAuthMethod_ShouldFailForUnknownMethod(t *testing.T) {
+	op := &Provider{}
+
+	_, err := op.authMethod(dotf.GitConfig{Auth: dotf.GitAuth{Method: "kerberos"}})
+	if err == nil {
+		t.Fatal("expected err not to be nil")
+	}
+}
+
+func TestAuthMethod_ShouldLoadSSHKeyFile(t *testing.T) {
+	dir, key := writeKey(t, nil)
+	defer os.RemoveAll(dir)
+
+	op := &Provider{}
+
+	auth, err := op.authMethod(dotf.GitConfig{Auth: dotf.GitAuth{Method: "ssh-key", KeyFile: key}})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	publicKeys, ok := auth.(*ssh.PublicKeys)
+	if !ok || publicKeys.User != "git" {
+		t.Fatalf("expected public keys auth for user git, got %v", auth)
+	}
+}
+
+func TestAuthMethod_ShouldDecryptSSHKeyWithPassphraseFromEnv(t *testing.T) {
+	dir, key := writeKey(t, []byte("secret"))
+	defer os.RemoveAll(dir)
+
+	setEnv(t, envSSHPassphrase, "secret")
+	defer os.Unsetenv(envSSHPassphrase)
+
+	op := &Provider{}
+
+	_, err := op.authMethod(dotf.GitConfig{Auth: dotf.GitAuth{Method: "ssh-key", User: "dotf", KeyFile: key}})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+}
+
+func TestAuthMethod_ShouldPreferEnvironmentOverConfig(t *testing.T) {
+	setEnv(t, envAuthMethod, "http-basic")
+	defer os.Unsetenv(envAuthMethod)
+	setEnv(t, envAuthUser, "jane")
+	defer os.Unsetenv(envAuthUser)
+	setEnv(t, envPassword, "hunter2")
+	defer os.Unsetenv(envPassword)
+
+	op := &Provider{}
+
+	auth, err := op.authMethod(dotf.GitConfig{Auth: dotf.GitAuth{Method: "ssh-agent", User: "git"}})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	basic, ok := auth.(*http.BasicAuth)
+	if !ok || basic.Username != "jane" || basic.Password != "hunter2" {
+		t.Fatalf("expected basic auth for jane, got %v", auth)
+	}
+}
+
+func TestAuthMethod_ShouldRequireTokenForTokenAuth(t *testing.T) {
+	op := &Provider{}
+
+	_, err := op.authMethod(dotf.GitConfig{Auth: dotf.GitAuth{Method: "http-token"}})
+	if err == nil {
+		t.Fatal("expected err not to be nil")
+	}
+
+	setEnv(t, envToken, "abc123")
+	defer os.Unsetenv(envToken)
+
+	auth, err := op.authMethod(dotf.GitConfig{Auth: dotf.GitAuth{Method: "http-token"}})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	token, ok := auth.(*http.TokenAuth)
+	if !ok || token.Token != "abc123" {
+		t.Fatalf("expected token auth, got %v", auth)
+	}
+}
+
+func TestAuthMethod_ShouldUseSSHAgent(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ssh agent stand-in requires unix sockets")
+	}
+
+	dir, err := ioutil.TempDir("", "dotf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyring := agent.NewKeyring()
+
+	err = keyring.Add(agent.AddedKey{PrivateKey: privateKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	socket := filepath.Join(dir, "agent.sock")
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go agent.ServeAgent(keyring, conn)
+		}
+	}()
+
+	setEnv(t, "SSH_AUTH_SOCK", socket)
+	defer os.Unsetenv("SSH_AUTH_SOCK")
+
+	op := &Provider{}
+
+	auth, err := op.authMethod(dotf.GitConfig{Auth: dotf.GitAuth{Method: "ssh-agent"}})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	callback, ok := auth.(*ssh.PublicKeysCallback)
+	if !ok {
+		t.Fatalf("expected public keys callback auth, got %v", auth)
+	}
+
+	signers, err := callback.Callback()
+	if err != nil || len(signers) != 1 {
+		t.Fatalf("expected one signer from the agent, got %v, %v", signers, err)
+	}
+}
+
+// writeKey writes a new RSA private key into a temporary directory. The key is
+// encrypted if a passphrase is given.
+func writeKey(t *testing.T, passphrase []byte) (string, string) {
+	dir, err := ioutil.TempDir("", "dotf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}
+
+	if passphrase != nil {
+		block, err = x509.EncryptPEMBlock(rand.Reader, block.Type, block.Bytes, passphrase, x509.PEMCipherAES256)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key := filepath.Join(dir, "id_rsa")
+
+	err = ioutil.WriteFile(key, pem.EncodeToMemory(block), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, key
+}
+
+func setEnv(t *testing.T, name, value string) {
+	err := os.Setenv(name, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
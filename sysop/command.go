@@ -0,0 +1,43 @@
+package sysop
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"bakku.dev/dotf"
+)
+
+// RunCommand runs a command line with the shell of the system and returns its
+// combined output and exit code. A command which exits non-zero is not an
+// error, err is only set if the command could not be run at all.
+func (sop *Provider) RunCommand(cmd dotf.Command) ([]byte, int, error) {
+	c := shellCommand(cmd.Line)
+	c.Dir = cmd.Dir
+	c.Env = append(os.Environ(), cmd.Env...)
+	c.Stdin = bytes.NewReader(cmd.Stdin)
+
+	output, err := c.CombinedOutput()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return output, exitErr.ExitCode(), nil
+	}
+
+	if err != nil {
+		return output, -1, fmt.Errorf("could not run %s: %v", cmd.Line, err)
+	}
+
+	return output, 0, nil
+}
+
+func shellCommand(line string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", line)
+	}
+
+	return exec.Command("sh", "-c", line)
+}
@@ -0,0 +1,276 @@
+package sysop
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"bakku.dev/dotf"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// repoRelativePath converts a path in the repo as stored in the dotf config
+// into the slash separated form go-git expects.
+func repoRelativePath(file string) string {
+	return strings.TrimPrefix(filepath.ToSlash(filepath.Clean(file)), "/")
+}
+
+// remoteName returns the configured remote or git's default remote.
+func remoteName(gitCfg dotf.GitConfig) string {
+	if gitCfg.Remote == "" {
+		return git.DefaultRemoteName
+	}
+
+	return gitCfg.Remote
+}
+
+// branchName returns the configured branch or, if none is configured, the
+// branch currently checked out in the repo.
+func branchName(repo *git.Repository, gitCfg dotf.GitConfig) (string, error) {
+	if gitCfg.Branch != "" {
+		return gitCfg.Branch, nil
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("could not get HEAD: %v", err)
+	}
+
+	if !head.Name().IsBranch() {
+		return "", fmt.Errorf("HEAD is not on a branch, please configure one")
+	}
+
+	return head.Name().Short(), nil
+}
+
+// branchRefSpec returns the refspec to push a local branch to the branch with
+// the same name on the remote.
+func branchRefSpec(branch string) config.RefSpec {
+	ref := plumbing.NewBranchReferenceName(branch)
+
+	return config.RefSpec(ref.String() + ":" + ref.String())
+}
+
+// tagRefSpec returns a refspec which updates the remote tag of the same name.
+func tagRefSpec(name string) config.RefSpec {
+	ref := plumbing.NewTagReferenceName(name)
+
+	return config.RefSpec(ref.String() + ":" + ref.String())
+}
+
+// describeTag reads the tag object a tag reference points to. Lightweight tags
+// point to a commit directly and are described by that commit.
+func describeTag(repo *git.Repository, name string, hash plumbing.Hash) (dotf.Tag, error) {
+	tagObject, err := repo.TagObject(hash)
+	if err == nil {
+		commit, err := tagObject.Commit()
+		if err != nil {
+			return dotf.Tag{}, fmt.Errorf("tag %s does not point to a commit: %v", name, err)
+		}
+
+		return dotf.Tag{
+			Name:    name,
+			Commit:  commit.Hash.String(),
+			When:    tagObject.Tagger.When,
+			Message: tagObject.Message,
+		}, nil
+	}
+
+	if err != plumbing.ErrObjectNotFound {
+		return dotf.Tag{}, err
+	}
+
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return dotf.Tag{}, fmt.Errorf("tag %s does not point to a commit: %v", name, err)
+	}
+
+	return dotf.Tag{
+		Name:    name,
+		Commit:  commit.Hash.String(),
+		When:    commit.Committer.When,
+		Message: commit.Message,
+	}, nil
+}
+
+// checkoutBranch makes sure the given branch is checked out. If it does not
+// exist locally yet it is created from the fetched remote branch.
+func checkoutBranch(repo *git.Repository, workTree *git.Worktree, remote, branch string) error {
+	local := plumbing.NewBranchReferenceName(branch)
+
+	head, err := repo.Head()
+	if err == nil && head.Name() == local {
+		return nil
+	}
+
+	_, err = repo.Reference(local, false)
+	if err == nil {
+		return workTree.Checkout(&git.CheckoutOptions{Branch: local})
+	}
+
+	remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName(remote, branch), true)
+	if err != nil {
+		return fmt.Errorf("branch %s does not exist on remote %s", branch, remote)
+	}
+
+	return workTree.Checkout(&git.CheckoutOptions{Branch: local, Hash: remoteRef.Hash(), Create: true})
+}
+
+// author returns the configured commit author. If neither name nor email are
+// configured nil is returned so go-git falls back to the git config of the
+// user. Configuring only one of them is an error.
+func author(gitCfg dotf.GitConfig) (*object.Signature, error) {
+	switch {
+	case gitCfg.AuthorName == "" && gitCfg.AuthorEmail == "":
+		return nil, nil
+	case gitCfg.AuthorName == "":
+		return nil, fmt.Errorf("git authorEmail is configured without authorName")
+	case gitCfg.AuthorEmail == "":
+		return nil, fmt.Errorf("git authorName is configured without authorEmail")
+	}
+
+	return &object.Signature{Name: gitCfg.AuthorName, Email: gitCfg.AuthorEmail, When: time.Now()}, nil
+}
+
+// countUnpushedCommits counts the commits of the local branch which are not
+// reachable from the remote tracking branch.
+func countUnpushedCommits(repo *git.Repository, remote, branch string) (int, error) {
+	commits, err := unpushedCommits(repo, remote, branch)
+
+	return len(commits), err
+}
+
+// unpushedCommits returns the commits of the local branch which are not
+// reachable from the remote tracking branch, newest first.
+func unpushedCommits(repo *git.Repository, remote, branch string) ([]dotf.Commit, error) {
+	local, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err == plumbing.ErrReferenceNotFound {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	pushed := make(map[plumbing.Hash]bool)
+
+	remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName(remote, branch), true)
+	if err == nil {
+		remoteCommit, err := repo.CommitObject(remoteRef.Hash())
+		if err != nil {
+			return nil, err
+		}
+
+		err = object.NewCommitPreorderIter(remoteCommit, nil, nil).ForEach(func(c *object.Commit) error {
+			pushed[c.Hash] = true
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	localCommit, err := repo.CommitObject(local.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []dotf.Commit
+
+	err = object.NewCommitPreorderIter(localCommit, pushed, nil).ForEach(func(c *object.Commit) error {
+		commits = append(commits, dotf.Commit{
+			Hash:    c.Hash.String(),
+			Author:  c.Author.Name,
+			When:    c.Author.When,
+			Message: c.Message,
+		})
+		return nil
+	})
+
+	return commits, err
+}
+
+// unstageFiles resets the index entries of files to the commit given by hash,
+// their changes stay in the worktree. Files which are not in the commit are
+// removed from the index.
+func unstageFiles(repo *git.Repository, hash plumbing.Hash, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return err
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		treeEntry, err := tree.FindEntry(file)
+		if err == object.ErrEntryNotFound || err == object.ErrDirectoryNotFound {
+			_, err = idx.Remove(file)
+			if err != nil && err != index.ErrEntryNotFound {
+				return err
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		entry, err := idx.Entry(file)
+		if err == index.ErrEntryNotFound {
+			entry = idx.Add(file)
+		} else if err != nil {
+			return err
+		}
+
+		entry.Hash = treeEntry.Hash
+		entry.Mode = treeEntry.Mode
+	}
+
+	return repo.Storer.SetIndex(idx)
+}
+
+// hasStagedChanges returns true if the index differs from HEAD.
+func hasStagedChanges(status git.Status) bool {
+	for _, fileStatus := range status {
+		if fileStatus.Staging != git.Unmodified && fileStatus.Staging != git.Untracked {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedKeys(m map[string]fileChange) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	return sortedStrings(keys)
+}
+
+func sortedStrings(s []string) []string {
+	sort.Strings(s)
+
+	return s
+}
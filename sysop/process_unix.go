@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package sysop
+
+import (
+	"errors"
+	"syscall"
+)
+
+// ProcessExists reports whether a process with the given id is running.
+func (sop *Provider) ProcessExists(pid int) bool {
+	err := syscall.Kill(pid, 0)
+
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
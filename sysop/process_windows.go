@@ -0,0 +1,22 @@
+//go:build windows
+// +build windows
+
+package sysop
+
+import (
+	"syscall"
+)
+
+const processQueryLimitedInformation = 0x1000
+
+// ProcessExists reports whether a process with the given id is running.
+func (sop *Provider) ProcessExists(pid int) bool {
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+
+	syscall.CloseHandle(handle)
+
+	return true
+}
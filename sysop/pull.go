@@ -0,0 +1,381 @@
+package sysop
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"bakku.dev/dotf"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/crypto/openpgp"
+)
+
+// fileChange is the state of a file after a range of commits. A nil file
+// means the file was deleted.
+type fileChange struct {
+	hash plumbing.Hash
+	file *object.File
+}
+
+// integrateRemoteBranch brings the local branch up to date with the fetched
+// remote branch. Fast-forwards are always done, diverged histories are handled
+// according to the pull strategy.
+func (sop *Provider) integrateRemoteBranch(repo *git.Repository, workTree *git.Worktree, remote, branch string, gitCfg dotf.GitConfig) error {
+	remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName(remote, branch), true)
+	if err != nil {
+		return fmt.Errorf("branch %s does not exist on remote %s", branch, remote)
+	}
+
+	localRef, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil {
+		return err
+	}
+
+	if localRef.Hash() == remoteRef.Hash() {
+		return nil
+	}
+
+	localCommit, err := repo.CommitObject(localRef.Hash())
+	if err != nil {
+		return err
+	}
+
+	remoteCommit, err := repo.CommitObject(remoteRef.Hash())
+	if err != nil {
+		return err
+	}
+
+	behind, err := localCommit.IsAncestor(remoteCommit)
+	if err != nil {
+		return err
+	}
+
+	if behind {
+		err = workTree.Reset(&git.ResetOptions{Commit: remoteCommit.Hash, Mode: git.MergeReset})
+		if err == git.ErrUnstagedChanges {
+			return fmt.Errorf("the repo contains uncommitted changes, commit or discard them before pulling")
+		}
+
+		return err
+	}
+
+	ahead, err := remoteCommit.IsAncestor(localCommit)
+	if err != nil || ahead {
+		return err
+	}
+
+	remoteBranch := remote + "/" + branch
+
+	switch strategy := gitCfg.PullStrategy; strategy {
+	case "", dotf.PullFastForward:
+		return fmt.Errorf(
+			"%s and %s have diverged, pull with the %s, %s or %s strategy to combine them",
+			branch, remoteBranch, dotf.PullMerge, dotf.PullRebase, dotf.PullReset,
+		)
+	case dotf.PullReset:
+		err = workTree.Reset(&git.ResetOptions{Commit: remoteCommit.Hash, Mode: git.HardReset})
+		if err != nil {
+			return err
+		}
+
+		sop.Log(fmt.Sprintf("reset %s to %s, the previous commit was %s\n", branch, remoteBranch, localCommit.Hash))
+
+		return nil
+	case dotf.PullMerge, dotf.PullRebase:
+		return sop.combineDivergedBranches(repo, workTree, localCommit, remoteCommit, remoteBranch, gitCfg)
+	default:
+		return fmt.Errorf("unknown pull strategy %s", strategy)
+	}
+}
+
+// combineDivergedBranches merges or rebases the local commits onto the remote
+// commits. Both are only possible if local and remote commits changed
+// different files, since dotf tracks whole files.
+func (sop *Provider) combineDivergedBranches(repo *git.Repository, workTree *git.Worktree, localCommit, remoteCommit *object.Commit, remoteBranch string, gitCfg dotf.GitConfig) error {
+	status, err := workTree.Status()
+	if err != nil {
+		return err
+	}
+
+	for _, fileStatus := range status {
+		if fileStatus.Worktree != git.Untracked || fileStatus.Staging != git.Untracked {
+			return fmt.Errorf("the repo contains uncommitted changes, commit or discard them before pulling")
+		}
+	}
+
+	bases, err := localCommit.MergeBase(remoteCommit)
+	if err != nil {
+		return err
+	}
+
+	if len(bases) == 0 {
+		return fmt.Errorf("the local branch and %s have no common history, pull with the %s strategy to use %s", remoteBranch, dotf.PullReset, remoteBranch)
+	}
+
+	localChanges, err := changedFiles(bases[0], localCommit)
+	if err != nil {
+		return err
+	}
+
+	remoteChanges, err := changedFiles(bases[0], remoteCommit)
+	if err != nil {
+		return err
+	}
+
+	var conflicts []string
+
+	for file, change := range localChanges {
+		if remoteChange, ok := remoteChanges[file]; ok && remoteChange.hash != change.hash {
+			conflicts = append(conflicts, file)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf(
+			"local and remote commits both changed %s, pull with the %s strategy to drop the local commits or combine the changes with git in %s",
+			strings.Join(sortedStrings(conflicts), ", "), dotf.PullReset, workTree.Filesystem.Root(),
+		)
+	}
+
+	signKey, err := sop.signingKey(gitCfg)
+	if err != nil {
+		return err
+	}
+
+	commitAuthor, err := author(gitCfg)
+	if err != nil {
+		return err
+	}
+
+	if gitCfg.PullStrategy == dotf.PullMerge {
+		err = mergeOnto(workTree, localCommit, remoteCommit, localChanges, remoteBranch, commitAuthor, signKey)
+	} else {
+		err = rebaseOnto(workTree, localCommit, remoteCommit, bases[0], commitAuthor, signKey)
+	}
+
+	if err != nil {
+		return restoreLocalCommit(workTree, localCommit, err)
+	}
+
+	return nil
+}
+
+// mergeOnto resets the branch to the remote commit, applies the local changes
+// and commits them as merge of both commits.
+func mergeOnto(workTree *git.Worktree, localCommit, remoteCommit *object.Commit, localChanges map[string]fileChange, remoteBranch string, commitAuthor *object.Signature, signKey *openpgp.Entity) error {
+	err := resetAndApply(workTree, remoteCommit, localChanges)
+	if err != nil {
+		return err
+	}
+
+	_, err = workTree.Commit(
+		fmt.Sprintf("Merge %s", remoteBranch),
+		&git.CommitOptions{
+			Author:  commitAuthor,
+			Parents: []plumbing.Hash{localCommit.Hash, remoteCommit.Hash},
+			SignKey: signKey,
+		},
+	)
+
+	return err
+}
+
+// rebaseOnto resets the branch to the remote commit and reapplies the local
+// commits since base on top of it.
+func rebaseOnto(workTree *git.Worktree, localCommit, remoteCommit, base *object.Commit, commitAuthor *object.Signature, signKey *openpgp.Entity) error {
+	localCommits, err := commitsSince(localCommit, base)
+	if err != nil {
+		return err
+	}
+
+	err = workTree.Reset(&git.ResetOptions{Commit: remoteCommit.Hash, Mode: git.HardReset})
+	if err != nil {
+		return err
+	}
+
+	for _, commit := range localCommits {
+		err = reapplyCommit(workTree, commit, commitAuthor, signKey)
+		if err != nil {
+			return fmt.Errorf("could not rebase commit %s: %v", commit.Hash, err)
+		}
+	}
+
+	return nil
+}
+
+// restoreLocalCommit resets the branch back to the local commit after merging
+// or rebasing failed, so the local commits stay on the branch. It returns
+// cause, extended by the error of the reset if that failed too.
+func restoreLocalCommit(workTree *git.Worktree, localCommit *object.Commit, cause error) error {
+	err := workTree.Reset(&git.ResetOptions{Commit: localCommit.Hash, Mode: git.HardReset})
+	if err != nil {
+		return fmt.Errorf("%v, resetting back to the local commit %s failed as well: %v", cause, localCommit.Hash, err)
+	}
+
+	return cause
+}
+
+// changedFiles returns the files which differ between two commits, keyed by
+// their path in the repository.
+func changedFiles(from, to *object.Commit) (map[string]fileChange, error) {
+	fromTree, err := from.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	toTree, err := to.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]fileChange)
+
+	for _, change := range changes {
+		fromFile, toFile, err := change.Files()
+		if err != nil {
+			return nil, err
+		}
+
+		if toFile == nil {
+			files[fromFile.Name] = fileChange{}
+			continue
+		}
+
+		files[toFile.Name] = fileChange{hash: toFile.Hash, file: toFile}
+	}
+
+	return files, nil
+}
+
+// commitsSince returns the first parent chain from commit down to, but not
+// including, base, oldest commit first.
+func commitsSince(commit, base *object.Commit) ([]*object.Commit, error) {
+	var commits []*object.Commit
+
+	for commit.Hash != base.Hash {
+		commits = append([]*object.Commit{commit}, commits...)
+
+		if commit.NumParents() == 0 {
+			break
+		}
+
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+
+		commit = parent
+	}
+
+	return commits, nil
+}
+
+// reapplyCommit applies the changes of commit on top of HEAD and commits them
+// with the original message and author, committed by committer. The new
+// commit is signed with signKey if it is not nil.
+func reapplyCommit(workTree *git.Worktree, commit *object.Commit, committer *object.Signature, signKey *openpgp.Entity) error {
+	if commit.NumParents() == 0 {
+		return fmt.Errorf("cannot rebase a root commit")
+	}
+
+	parent, err := commit.Parent(0)
+	if err != nil {
+		return err
+	}
+
+	changes, err := changedFiles(parent, commit)
+	if err != nil {
+		return err
+	}
+
+	err = applyChanges(workTree, changes)
+	if err != nil {
+		return err
+	}
+
+	status, err := workTree.Status()
+	if err != nil {
+		return err
+	}
+
+	if !hasStagedChanges(status) {
+		// the remote already contains these changes
+		return nil
+	}
+
+	signature := commit.Author
+
+	_, err = workTree.Commit(commit.Message, &git.CommitOptions{Author: &signature, Committer: committer, SignKey: signKey})
+
+	return err
+}
+
+// resetAndApply resets the worktree to commit and applies the changes on top
+// of it. The changes are staged but not committed.
+func resetAndApply(workTree *git.Worktree, commit *object.Commit, changes map[string]fileChange) error {
+	err := workTree.Reset(&git.ResetOptions{Commit: commit.Hash, Mode: git.HardReset})
+	if err != nil {
+		return err
+	}
+
+	return applyChanges(workTree, changes)
+}
+
+// applyChanges writes the changed files into the worktree and stages them.
+func applyChanges(workTree *git.Worktree, changes map[string]fileChange) error {
+	root := workTree.Filesystem.Root()
+
+	for _, name := range sortedKeys(changes) {
+		change := changes[name]
+		path := filepath.Join(root, filepath.FromSlash(name))
+
+		if change.file == nil {
+			err := os.Remove(path)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		} else {
+			content, err := change.file.Contents()
+			if err != nil {
+				return err
+			}
+
+			mode, err := change.file.Mode.ToOSFileMode()
+			if err != nil {
+				return err
+			}
+
+			err = os.MkdirAll(filepath.Dir(path), 0755)
+			if err != nil {
+				return err
+			}
+
+			err = ioutil.WriteFile(path, []byte(content), mode)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := workTree.Add(name)
+		if err == index.ErrEntryNotFound && change.file == nil {
+			// the removed file was never staged
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
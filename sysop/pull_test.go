@@ -0,0 +1,203 @@
+package sysop_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bakku.dev/dotf"
+	"bakku.dev/dotf/sysop"
+)
+
+func TestUpdateRepo_ShouldFastForward(t *testing.T) {
+	path, remote, cleanup := setupRepo(t)
+	defer cleanup()
+
+	op := sysop.Provider{}
+	other := cloneRepo(t, remote, filepath.Join(filepath.Dir(path), "other"))
+	commitFile(t, &op, other, ".vimrc", "set number", true)
+
+	err := op.UpdateRepo(path, dotf.GitConfig{})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	if headHash(t, path) != headHash(t, remote) {
+		t.Fatal("expected repo to be fast-forwarded to the remote")
+	}
+}
+
+func TestUpdateRepo_ShouldFailForDivergedHistoriesWithFastForwardOnly(t *testing.T) {
+	path, _, cleanup := setupDivergedRepo(t, ".bashrc")
+	defer cleanup()
+
+	op := sysop.Provider{}
+
+	err := op.UpdateRepo(path, dotf.GitConfig{PullStrategy: dotf.PullFastForward})
+	if err == nil || !strings.Contains(err.Error(), "have diverged") {
+		t.Fatalf("expected diverged error, got %v", err)
+	}
+}
+
+func TestUpdateRepo_ShouldMergeDivergedHistories(t *testing.T) {
+	path, remote, cleanup := setupDivergedRepo(t, ".bashrc")
+	defer cleanup()
+
+	local := headHash(t, path)
+	op := sysop.Provider{}
+
+	err := op.UpdateRepo(path, dotf.GitConfig{PullStrategy: dotf.PullMerge})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	commit := headCommit(t, path)
+
+	if len(commit.ParentHashes) != 2 ||
+		commit.ParentHashes[0].String() != local ||
+		commit.ParentHashes[1].String() != headHash(t, remote) {
+		t.Fatalf("expected merge commit of local and remote, got parents %v", commit.ParentHashes)
+	}
+
+	assertFileContent(t, filepath.Join(path, ".vimrc"), "set number")
+	assertFileContent(t, filepath.Join(path, ".bashrc"), "remote")
+}
+
+func TestUpdateRepo_ShouldRebaseDivergedHistories(t *testing.T) {
+	path, remote, cleanup := setupDivergedRepo(t, ".bashrc")
+	defer cleanup()
+
+	op := sysop.Provider{}
+
+	err := op.UpdateRepo(path, dotf.GitConfig{PullStrategy: dotf.PullRebase})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	commit := headCommit(t, path)
+
+	if len(commit.ParentHashes) != 1 || commit.ParentHashes[0].String() != headHash(t, remote) {
+		t.Fatalf("expected local commit on top of remote, got parents %v", commit.ParentHashes)
+	}
+
+	if commit.Message != "Update .vimrc" {
+		t.Fatalf("expected message of local commit to be kept, got %s", commit.Message)
+	}
+
+	assertFileContent(t, filepath.Join(path, ".vimrc"), "set number")
+	assertFileContent(t, filepath.Join(path, ".bashrc"), "remote")
+}
+
+func TestUpdateRepo_ShouldRefuseToCombineConflictingChanges(t *testing.T) {
+	for _, strategy := range []string{dotf.PullMerge, dotf.PullRebase} {
+		path, _, cleanup := setupDivergedRepo(t, ".vimrc")
+
+		op := sysop.Provider{}
+		local := headHash(t, path)
+
+		err := op.UpdateRepo(path, dotf.GitConfig{PullStrategy: strategy})
+		if err == nil || !strings.Contains(err.Error(), "both changed .vimrc") {
+			t.Fatalf("expected conflict error for %s, got %v", strategy, err)
+		}
+
+		if headHash(t, path) != local {
+			t.Fatalf("expected repo to be unchanged for %s", strategy)
+		}
+
+		cleanup()
+	}
+}
+
+func TestUpdateRepo_ShouldResetToRemote(t *testing.T) {
+	path, remote, cleanup := setupDivergedRepo(t, ".vimrc")
+	defer cleanup()
+
+	op := sysop.Provider{}
+
+	err := op.UpdateRepo(path, dotf.GitConfig{PullStrategy: dotf.PullReset})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	if headHash(t, path) != headHash(t, remote) {
+		t.Fatal("expected repo to be reset to the remote")
+	}
+
+	assertFileContent(t, filepath.Join(path, ".vimrc"), "remote")
+}
+
+// setupDivergedRepo creates a repo with a local commit changing .vimrc while
+// the remote got a commit changing remoteFile.
+func setupDivergedRepo(t *testing.T, remoteFile string) (string, string, func()) {
+	path, remote, cleanup := setupRepo(t)
+
+	op := sysop.Provider{}
+	other := cloneRepo(t, remote, filepath.Join(filepath.Dir(path), "other"))
+	commitFile(t, &op, other, remoteFile, "remote", true)
+	commitFile(t, &op, path, ".vimrc", "set number", false)
+
+	return path, remote, cleanup
+}
+
+func commitFile(t *testing.T, op *sysop.Provider, path, file, content string, push bool) {
+	writeFile(t, filepath.Join(path, file), content)
+
+	err := op.CommitRepo(path, "Update "+file, []string{file}, dotf.GitConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if push {
+		err = op.PushRepo(path, dotf.GitConfig{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func assertFileContent(t *testing.T, path, expected string) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != expected {
+		t.Fatalf("expected %s to contain %q, got %q", path, expected, content)
+	}
+}
+
+func TestUpdateRepo_ShouldKeepLocalCommitsIfCombiningFails(t *testing.T) {
+	for _, strategy := range []string{dotf.PullMerge, dotf.PullRebase} {
+		path, remote, cleanup := setupRepo(t)
+
+		// the remote turns .vimrc into a directory, so the local .vimrc cannot
+		// be written on top of it
+		op := sysop.Provider{}
+		other := cloneRepo(t, remote, filepath.Join(filepath.Dir(path), "other"))
+
+		err := os.MkdirAll(filepath.Join(other, ".vimrc"), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		commitFile(t, &op, other, ".vimrc/colors", "remote", true)
+		commitFile(t, &op, path, ".vimrc", "set number", false)
+
+		local := headHash(t, path)
+
+		err = op.UpdateRepo(path, dotf.GitConfig{PullStrategy: strategy})
+		if err == nil {
+			t.Fatalf("expected err not to be nil for %s", strategy)
+		}
+
+		if headHash(t, path) != local {
+			t.Fatalf("expected branch to be reset to the local commit for %s", strategy)
+		}
+
+		assertFileContent(t, filepath.Join(path, ".vimrc"), "set number")
+
+		cleanup()
+	}
+}
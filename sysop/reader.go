@@ -0,0 +1,88 @@
+package sysop
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"bakku.dev/dotf"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// worktreeReader serves tracked files from the checkout on disk.
+type worktreeReader struct {
+	path string
+}
+
+func (r worktreeReader) ReadFile(pathInRepo string) ([]byte, error) {
+	file := filepath.Join(r.path, filepath.FromSlash(repoRelativePath(pathInRepo)))
+
+	content, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, dotf.ErrNotInRepo
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %v", file, err)
+	}
+
+	return content, nil
+}
+
+// treeReader serves tracked files from the tree of a commit.
+type treeReader struct {
+	rev  string
+	tree *object.Tree
+}
+
+func (r treeReader) ReadFile(pathInRepo string) ([]byte, error) {
+	file, err := r.tree.File(repoRelativePath(pathInRepo))
+	if err == object.ErrFileNotFound {
+		return nil, dotf.ErrNotInRepo
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s at revision %s: %v", pathInRepo, r.rev, err)
+	}
+
+	content, err := file.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s at revision %s: %v", pathInRepo, r.rev, err)
+	}
+
+	return []byte(content), nil
+}
+
+// OpenRepoReader returns a reader for the files of a git repository as they
+// are in the given commit, tag or branch. If rev is empty the files are read
+// from the worktree instead.
+func (sop *Provider) OpenRepoReader(path, rev string) (dotf.RepoReader, error) {
+	if rev == "" {
+		return worktreeReader{path: path}, nil
+	}
+
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open repo %s: %v", path, err)
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve revision %s in repo %s: %v", rev, path, err)
+	}
+
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("could not read revision %s in repo %s: %v", rev, path, err)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("could not read tree of revision %s in repo %s: %v", rev, path, err)
+	}
+
+	return treeReader{rev: rev, tree: tree}, nil
+}
@@ -0,0 +1,217 @@
+package sysop
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"bakku.dev/dotf"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"golang.org/x/crypto/openpgp"
+	pgperrors "golang.org/x/crypto/openpgp/errors"
+)
+
+// envSigningPassphrase provides the passphrase of the signing key so it does
+// not have to be prompted for.
+const envSigningPassphrase = "DOTF_GIT_SIGNING_PASSPHRASE"
+
+// signingKey loads the configured OpenPGP signing key. The result is cached so
+// the passphrase is only prompted for once per run. Without a configured key
+// nil is returned and commits stay unsigned.
+func (sop *Provider) signingKey(gitCfg dotf.GitConfig) (*openpgp.Entity, error) {
+	if sop.signKeyResolved {
+		return sop.signKey, nil
+	}
+
+	if gitCfg.SigningKey == "" {
+		sop.signKeyResolved = true
+
+		return nil, nil
+	}
+
+	keyring, err := sop.readKeyRing(gitCfg.SigningKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var entity *openpgp.Entity
+
+	for _, candidate := range keyring {
+		if candidate.PrivateKey != nil {
+			entity = candidate
+			break
+		}
+	}
+
+	if entity == nil {
+		return nil, fmt.Errorf("signing key %s contains no private key", gitCfg.SigningKey)
+	}
+
+	err = sop.unlockKey(entity, gitCfg.SigningKey)
+	if err != nil {
+		return nil, err
+	}
+
+	sop.signKey = entity
+	sop.signKeyResolved = true
+
+	return entity, nil
+}
+
+// unlockKey decrypts the private keys of entity with a passphrase from the
+// environment or prompted from the user.
+func (sop *Provider) unlockKey(entity *openpgp.Entity, keyFile string) error {
+	encrypted := entity.PrivateKey.Encrypted
+
+	for _, subkey := range entity.Subkeys {
+		encrypted = encrypted || (subkey.PrivateKey != nil && subkey.PrivateKey.Encrypted)
+	}
+
+	if !encrypted {
+		return nil
+	}
+
+	passphrase := sop.GetEnvVar(envSigningPassphrase)
+
+	if passphrase == "" {
+		var err error
+
+		sop.Warn(fmt.Sprintf("Passphrase for signing key %s: ", keyFile))
+
+		passphrase, err = sop.ReadLine()
+		if err != nil {
+			return err
+		}
+	}
+
+	if entity.PrivateKey.Encrypted {
+		err := entity.PrivateKey.Decrypt([]byte(passphrase))
+		if err != nil {
+			return fmt.Errorf("could not unlock signing key %s: %v", keyFile, err)
+		}
+	}
+
+	for _, subkey := range entity.Subkeys {
+		if subkey.PrivateKey != nil && subkey.PrivateKey.Encrypted {
+			err := subkey.PrivateKey.Decrypt([]byte(passphrase))
+			if err != nil {
+				return fmt.Errorf("could not unlock signing key %s: %v", keyFile, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// readKeyRing reads an armored or binary OpenPGP key file.
+func (sop *Provider) readKeyRing(keyFile string) (openpgp.EntityList, error) {
+	keyFile = sop.expandHome(keyFile)
+
+	data, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read OpenPGP key %s: %v", keyFile, err)
+	}
+
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	if err != nil {
+		keyring, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not parse OpenPGP key %s: %v", keyFile, err)
+	}
+
+	return keyring, nil
+}
+
+// trustedKeyRing collects the public keys of the signing key and all trusted
+// keys of the config.
+func (sop *Provider) trustedKeyRing(gitCfg dotf.GitConfig) (openpgp.EntityList, error) {
+	var keyring openpgp.EntityList
+
+	keyFiles := gitCfg.TrustedKeys
+	if gitCfg.SigningKey != "" {
+		keyFiles = append([]string{gitCfg.SigningKey}, keyFiles...)
+	}
+
+	for _, keyFile := range keyFiles {
+		keys, err := sop.readKeyRing(keyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		keyring = append(keyring, keys...)
+	}
+
+	return keyring, nil
+}
+
+// VerifyCommit checks the OpenPGP signature of a commit against the signing
+// key and the trusted keys of the config.
+func (sop *Provider) VerifyCommit(path, hash string, gitCfg dotf.GitConfig) (dotf.Signature, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return dotf.Signature{}, fmt.Errorf("could not open repo %s: %v", path, err)
+	}
+
+	commit, err := repo.CommitObject(plumbing.NewHash(hash))
+	if err != nil {
+		return dotf.Signature{}, fmt.Errorf("could not read commit %s in repo %s: %v", hash, path, err)
+	}
+
+	if commit.PGPSignature == "" {
+		return dotf.Signature{State: dotf.SignatureNone}, nil
+	}
+
+	keyring, err := sop.trustedKeyRing(gitCfg)
+	if err != nil {
+		return dotf.Signature{}, err
+	}
+
+	encoded := &plumbing.MemoryObject{}
+
+	err = commit.EncodeWithoutSignature(encoded)
+	if err != nil {
+		return dotf.Signature{}, fmt.Errorf("could not encode commit %s: %v", hash, err)
+	}
+
+	reader, err := encoded.Reader()
+	if err != nil {
+		return dotf.Signature{}, fmt.Errorf("could not encode commit %s: %v", hash, err)
+	}
+
+	signer, err := openpgp.CheckArmoredDetachedSignature(keyring, reader, strings.NewReader(commit.PGPSignature))
+	if err == pgperrors.ErrUnknownIssuer {
+		return dotf.Signature{State: dotf.SignatureUnknown}, nil
+	}
+
+	if err != nil {
+		return dotf.Signature{State: dotf.SignatureBad}, nil
+	}
+
+	return dotf.Signature{State: dotf.SignatureGood, Signer: primaryIdentity(signer)}, nil
+}
+
+// primaryIdentity returns the name of the identity marked as primary. Without
+// such a mark the alphabetically first identity is used.
+func primaryIdentity(entity *openpgp.Entity) string {
+	var primary string
+
+	for name, identity := range entity.Identities {
+		if identity.SelfSignature != nil && identity.SelfSignature.IsPrimaryId != nil && *identity.SelfSignature.IsPrimaryId {
+			return name
+		}
+
+		if primary == "" || name < primary {
+			primary = name
+		}
+	}
+
+	if primary == "" {
+		return entity.PrimaryKey.KeyIdString()
+	}
+
+	return primary
+}
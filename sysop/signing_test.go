@@ -0,0 +1,82 @@
+package sysop_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bakku.dev/dotf"
+	"bakku.dev/dotf/sysop"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func TestCommitRepo_ShouldSignCommitsWithConfiguredKey(t *testing.T) {
+	path, _, cleanup := setupRepo(t)
+	defer cleanup()
+
+	signingKey := writeSigningKey(t, filepath.Join(path, "..", "signing.asc"), "jane")
+	otherKey := writeSigningKey(t, filepath.Join(path, "..", "other.asc"), "john")
+
+	op := sysop.Provider{}
+
+	writeFile(t, filepath.Join(path, ".vimrc"), "set number")
+
+	err := op.CommitRepo(path, "Add .vimrc", []string{"/.vimrc"}, dotf.GitConfig{SigningKey: signingKey})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	if headCommit(t, path).PGPSignature == "" {
+		t.Fatalf("expected commit to be signed")
+	}
+
+	signature, err := op.VerifyCommit(path, headHash(t, path), dotf.GitConfig{SigningKey: signingKey})
+	if err != nil || signature.State != dotf.SignatureGood || signature.Signer != "jane <jane@localhost>" {
+		t.Fatalf("expected good signature by jane, got %v, %v", signature, err)
+	}
+
+	signature, err = op.VerifyCommit(path, headHash(t, path), dotf.GitConfig{TrustedKeys: []string{otherKey}})
+	if err != nil || signature.State != dotf.SignatureUnknown {
+		t.Fatalf("expected signature by unknown key, got %v, %v", signature, err)
+	}
+
+	initial := headCommit(t, path).ParentHashes[0].String()
+
+	signature, err = op.VerifyCommit(path, initial, dotf.GitConfig{SigningKey: signingKey})
+	if err != nil || signature.State != dotf.SignatureNone {
+		t.Fatalf("expected initial commit to be unsigned, got %v, %v", signature, err)
+	}
+}
+
+// writeSigningKey generates an unencrypted OpenPGP key for name and writes it
+// armored to path.
+func writeSigningKey(t *testing.T, path, name string) string {
+	entity, err := openpgp.NewEntity(name, "", name+"@localhost", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	writer, err := armor.Encode(file, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = entity.SerializePrivate(writer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
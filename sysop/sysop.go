@@ -2,19 +2,35 @@ package sysop
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"bakku.dev/dotf"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"golang.org/x/crypto/openpgp"
 )
 
 // Provider implements the dotf.SysOpProvider interface.
-type Provider struct{}
+type Provider struct {
+	auth            transport.AuthMethod
+	authResolved    bool
+	signKey         *openpgp.Entity
+	signKeyResolved bool
+	// stdin is shared by all ReadLine calls, a reader per call would drop the
+	// lines it buffered beyond the first one.
+	stdin *bufio.Reader
+}
 
 // GetEnvVar returns an environment variable of the current environment.
 func (sop *Provider) GetEnvVar(s string) string {
@@ -26,6 +42,31 @@ func (sop *Provider) GetPathSep() string {
 	return string(filepath.Separator)
 }
 
+// GetHostname returns the host name of the current machine.
+func (sop *Provider) GetHostname() (string, error) {
+	return os.Hostname()
+}
+
+// Executable returns the path of the running dotf binary.
+func (sop *Provider) Executable() (string, error) {
+	return os.Executable()
+}
+
+// Now returns the current local time.
+func (sop *Provider) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep pauses the current goroutine for d.
+func (sop *Provider) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// GetPid returns the process id of dotf.
+func (sop *Provider) GetPid() int {
+	return os.Getpid()
+}
+
 // CleanPath cleans the given path from common error sources and returns it
 func (sop *Provider) CleanPath(path string) string {
 	return filepath.Clean(path)
@@ -50,11 +91,19 @@ func (sop *Provider) Log(message string) {
 	fmt.Print(message)
 }
 
+// Warn writes prompts and diagnostics to STDERR, so STDOUT only carries the
+// output of a command.
+func (sop *Provider) Warn(message string) {
+	fmt.Fprint(os.Stderr, message)
+}
+
 // ReadLine reads a line from STDIN.
 func (sop *Provider) ReadLine() (string, error) {
-	reader := bufio.NewReader(os.Stdin)
+	if sop.stdin == nil {
+		sop.stdin = bufio.NewReader(os.Stdin)
+	}
 
-	text, err := reader.ReadString('\n')
+	text, err := sop.stdin.ReadString('\n')
 	if err != nil {
 		return "", fmt.Errorf("error while reading: %v", err)
 	}
@@ -73,7 +122,13 @@ func (sop *Provider) DeserializeConfig(raw []byte, c *dotf.Config) error {
 }
 
 // WriteFile takes a path and content and (over)writes the content to the given path.
+// Missing parent directories are created.
 func (sop *Provider) WriteFile(path string, content []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return fmt.Errorf("could not create directory for %s", path)
+	}
+
 	return ioutil.WriteFile(path, content, 0644)
 }
 
@@ -82,7 +137,167 @@ func (sop *Provider) ReadFile(path string) ([]byte, error) {
 	return ioutil.ReadFile(path)
 }
 
-// CopyFile copies and overwrites src to dest.
+// CreateLockFile creates a file with the given content unless it already
+// exists. It returns false if the file exists.
+func (sop *Provider) CreateLockFile(path string, content []byte) (bool, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("could not create %s: %v", path, err)
+	}
+
+	_, err = file.Write(content)
+	if err != nil {
+		file.Close()
+		os.Remove(path)
+		return false, fmt.Errorf("could not write %s: %v", path, err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		os.Remove(path)
+		return false, fmt.Errorf("could not write %s: %v", path, err)
+	}
+
+	return true, nil
+}
+
+// RemoveLockFile removes the lock file at path if it still has the given
+// content and returns false otherwise. The file is renamed aside first, so
+// a lock which is created again meanwhile is never removed but put back.
+func (sop *Provider) RemoveLockFile(path string, content []byte) (bool, error) {
+	aside := fmt.Sprintf("%s.%d.stale", path, os.Getpid())
+
+	err := os.Rename(path, aside)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("could not move %s aside: %v", path, err)
+	}
+	defer os.Remove(aside)
+
+	current, err := ioutil.ReadFile(aside)
+	if err == nil && bytes.Equal(current, content) {
+		return true, nil
+	}
+
+	// os.Link fails instead of replacing a lock which was taken after the rename
+	linkErr := os.Link(aside, path)
+	if linkErr != nil && !os.IsExist(linkErr) {
+		return false, fmt.Errorf("could not put back lock %s: %v", path, linkErr)
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("could not read %s: %v", path, err)
+	}
+
+	return false, nil
+}
+
+// RemoveFile removes a file. A file which does not exist is ignored.
+func (sop *Provider) RemoveFile(path string) error {
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// replaceLockTimeout is how long ReplaceFile waits for another process which
+// replaces the same file.
+const replaceLockTimeout = 5 * time.Second
+
+// ReplaceFile replaces the content of path if it still equals expected and
+// returns dotf.ErrConflict otherwise. The new content is written to a
+// temporary file first, which is then renamed to path. Comparing and renaming
+// happens under an exclusive lock so concurrent replacements cannot overwrite
+// each other. If path is a symlink its target is replaced.
+func (sop *Provider) ReplaceFile(path string, expected, content []byte) error {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(target), "."+filepath.Base(target)+".")
+	if err != nil {
+		return fmt.Errorf("could not create temporary file for %s: %v", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(content)
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not write temporary file for %s: %v", path, err)
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return fmt.Errorf("could not write temporary file for %s: %v", path, err)
+	}
+
+	err = os.Chmod(tmp.Name(), info.Mode())
+	if err != nil {
+		return fmt.Errorf("could not set mode of temporary file for %s: %v", path, err)
+	}
+
+	unlock, err := lockReplacement(target)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := ioutil.ReadFile(target)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(current, expected) {
+		return dotf.ErrConflict
+	}
+
+	return os.Rename(tmp.Name(), target)
+}
+
+// lockReplacement takes an exclusive lock on replacing path by creating
+// path.replace.lock, waiting for another process holding it. The name differs
+// from the dotf lock so replacing the config works while the dotf lock is
+// held. The returned function releases the lock.
+func lockReplacement(path string) (func(), error) {
+	lockPath := path + ".replace.lock"
+	deadline := time.Now().Add(replaceLockTimeout)
+
+	for {
+		file, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("could not lock %s: %v", path, err)
+		}
+
+		if !time.Now().Before(deadline) {
+			return nil, fmt.Errorf("%s is still locked after waiting %s, remove %s if no dotf is running", path, replaceLockTimeout, lockPath)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// CopyFile copies and overwrites src to dest. Missing parent directories of
+// dest are created.
 func (sop *Provider) CopyFile(src, dest string) error {
 	// if src does not exist (yet) do not try to copy
 	if !sop.PathExists(src) {
@@ -94,6 +309,11 @@ func (sop *Provider) CopyFile(src, dest string) error {
 		return fmt.Errorf("could not read file %s", src)
 	}
 
+	err = os.MkdirAll(filepath.Dir(dest), 0755)
+	if err != nil {
+		return fmt.Errorf("could not create directory for %s", dest)
+	}
+
 	err = ioutil.WriteFile(dest, input, 0644)
 	if err != nil {
 		return fmt.Errorf("could not write file %s", src)
@@ -102,8 +322,30 @@ func (sop *Provider) CopyFile(src, dest string) error {
 	return nil
 }
 
-// UpdateRepo updates a git repository.
-func (sop *Provider) UpdateRepo(path string) error {
+// FetchRepo fetches the configured remote of a git repository without
+// changing the worktree.
+func (sop *Provider) FetchRepo(path string, gitCfg dotf.GitConfig) error {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return fmt.Errorf("could not open repo %s: %v", path, err)
+	}
+
+	auth, err := sop.authMethod(gitCfg)
+	if err != nil {
+		return fmt.Errorf("could not authenticate for repo %s: %v", path, err)
+	}
+
+	err = repo.Fetch(&git.FetchOptions{RemoteName: remoteName(gitCfg), Auth: auth, Tags: git.AllTags})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return fmt.Errorf("could not fetch repo %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// UpdateRepo fetches the configured remote and integrates the configured
+// branch into the local branch using the configured pull strategy.
+func (sop *Provider) UpdateRepo(path string, gitCfg dotf.GitConfig) error {
 	repo, err := git.PlainOpen(path)
 	if err != nil {
 		return fmt.Errorf("could not open repo %s: %v", path, err)
@@ -114,42 +356,486 @@ func (sop *Provider) UpdateRepo(path string) error {
 		return fmt.Errorf("could not get worktree of %s: %v", path, err)
 	}
 
-	err = workTree.Pull(&git.PullOptions{RemoteName: "origin"})
+	auth, err := sop.authMethod(gitCfg)
+	if err != nil {
+		return fmt.Errorf("could not authenticate for repo %s: %v", path, err)
+	}
+
+	remote := remoteName(gitCfg)
+
+	err = repo.Fetch(&git.FetchOptions{RemoteName: remote, Auth: auth})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return fmt.Errorf("could not fetch repo %s: %v", path, err)
+	}
+
+	branch, err := branchName(repo, gitCfg)
+	if err != nil {
+		return fmt.Errorf("could not pull repo %s: %v", path, err)
+	}
+
+	err = checkoutBranch(repo, workTree, remote, branch)
+	if err != nil {
+		return fmt.Errorf("could not pull repo %s: %v", path, err)
+	}
+
+	err = sop.integrateRemoteBranch(repo, workTree, remote, branch, gitCfg)
+	if err != nil {
+		return fmt.Errorf("could not pull repo %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// ResolveRevision resolves a commit hash, tag or branch of a git repository
+// to the full hash of the commit.
+func (sop *Provider) ResolveRevision(path, rev string) (string, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return "", fmt.Errorf("could not open repo %s: %v", path, err)
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return "", fmt.Errorf("could not resolve revision %s in repo %s: %v", rev, path, err)
+	}
+
+	return hash.String(), nil
+}
+
+// FileHistory lists the commits reachable from HEAD which changed the given
+// file, newest first.
+func (sop *Provider) FileHistory(path, file string) ([]dotf.Commit, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open repo %s: %v", path, err)
+	}
+
+	fileName := repoRelativePath(file)
+
+	iter, err := repo.Log(&git.LogOptions{FileName: &fileName})
+	if err != nil {
+		return nil, fmt.Errorf("could not read history of %s in repo %s: %v", file, path, err)
+	}
+
+	var commits []dotf.Commit
+
+	err = iter.ForEach(func(commit *object.Commit) error {
+		commits = append(commits, dotf.Commit{
+			Hash:    commit.Hash.String(),
+			Author:  commit.Author.Name,
+			When:    commit.Author.When,
+			Message: commit.Message,
+		})
+
+		return nil
+	})
 	if err != nil {
-		if err.Error() != "already up-to-date" {
-			return fmt.Errorf("could not pull repo %s: %v", path, err)
+		return nil, fmt.Errorf("could not read history of %s in repo %s: %v", file, path, err)
+	}
+
+	return commits, nil
+}
+
+// RepoChanges returns which of the given files differ from the last commit of
+// a git repository.
+func (sop *Provider) RepoChanges(path string, files []string) (dotf.FileChanges, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return dotf.FileChanges{}, fmt.Errorf("could not open repo %s: %v", path, err)
+	}
+
+	workTree, err := repo.Worktree()
+	if err != nil {
+		return dotf.FileChanges{}, fmt.Errorf("could not get worktree of %s: %v", path, err)
+	}
+
+	status, err := workTree.Status()
+	if err != nil {
+		return dotf.FileChanges{}, fmt.Errorf("could not get status of repo %s: %v", path, err)
+	}
+
+	changes := dotf.FileChanges{}
+
+	for _, file := range files {
+		file = repoRelativePath(file)
+
+		fileStatus, ok := status[file]
+		if !ok {
+			continue
+		}
+
+		switch {
+		case fileStatus.Worktree == git.Untracked || fileStatus.Staging == git.Added:
+			changes.Added = append(changes.Added, file)
+		case fileStatus.Worktree == git.Deleted || fileStatus.Staging == git.Deleted:
+			changes.Deleted = append(changes.Deleted, file)
+		case fileStatus.Worktree == git.Modified || fileStatus.Staging == git.Modified:
+			changes.Modified = append(changes.Modified, file)
 		}
 	}
 
+	return changes, nil
+}
+
+// DirtyFiles returns all files of a git repository with uncommitted changes.
+// Untracked files are ignored since pulling does not touch them.
+func (sop *Provider) DirtyFiles(path string) ([]string, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open repo %s: %v", path, err)
+	}
+
+	workTree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("could not get worktree of %s: %v", path, err)
+	}
+
+	status, err := workTree.Status()
+	if err != nil {
+		return nil, fmt.Errorf("could not get status of repo %s: %v", path, err)
+	}
+
+	var files []string
+
+	for file, fileStatus := range status {
+		if fileStatus.Worktree == git.Untracked && fileStatus.Staging == git.Untracked {
+			continue
+		}
+
+		if fileStatus.Worktree != git.Unmodified || fileStatus.Staging != git.Unmodified {
+			files = append(files, file)
+		}
+	}
+
+	return sortedStrings(files), nil
+}
+
+// DiscardChanges restores the given files of a git repository to their state
+// in the last commit, both in the worktree and the index. Files which are not
+// in the last commit are removed.
+func (sop *Provider) DiscardChanges(path string, files []string) error {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return fmt.Errorf("could not open repo %s: %v", path, err)
+	}
+
+	workTree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("could not get worktree of %s: %v", path, err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("could not get HEAD of %s: %v", path, err)
+	}
+
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("could not get HEAD of %s: %v", path, err)
+	}
+
+	changes := make(map[string]fileChange)
+
+	for _, file := range files {
+		file = repoRelativePath(file)
+
+		committed, err := commit.File(file)
+		if err == object.ErrFileNotFound {
+			changes[file] = fileChange{}
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not read %s from HEAD of %s: %v", file, path, err)
+		}
+
+		changes[file] = fileChange{hash: committed.Hash, file: committed}
+	}
+
+	err = applyChanges(workTree, changes)
+	if err != nil {
+		return fmt.Errorf("could not discard changes in repo %s: %v", path, err)
+	}
+
 	return nil
 }
 
-// CommitRepo commits and pushes a git repository.
-func (sop *Provider) CommitRepo(path, message string) error {
+// CommitRepo stages the given files and commits them to the configured branch
+// of a git repository. Other changes in the repository are not committed, only
+// reported. If none of the files changed dotf.ErrNothingToCommit is returned.
+func (sop *Provider) CommitRepo(path, message string, files []string, gitCfg dotf.GitConfig) error {
 	repo, err := git.PlainOpen(path)
 	if err != nil {
 		return fmt.Errorf("could not open repo %s: %v", path, err)
 	}
 
+	branch, err := branchName(repo, gitCfg)
+	if err != nil {
+		return fmt.Errorf("could not commit to repo %s: %v", path, err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("could not get HEAD of %s: %v", path, err)
+	}
+
+	if head.Name() != plumbing.NewBranchReferenceName(branch) {
+		return fmt.Errorf("repo %s is not on branch %s, please pull first", path, branch)
+	}
+
 	workTree, err := repo.Worktree()
 	if err != nil {
 		return fmt.Errorf("could not get worktree of %s: %v", path, err)
 	}
 
-	err = workTree.AddGlob(".")
+	staged := make(map[string]bool)
+
+	for _, file := range files {
+		file = repoRelativePath(file)
+
+		_, err = workTree.Add(file)
+		if err != nil {
+			// files which neither exist on disk nor in the repo cannot be staged
+			if !sop.PathExists(filepath.Join(path, file)) {
+				continue
+			}
+
+			return fmt.Errorf("could not add file %s to repo %s: %v", file, path, err)
+		}
+
+		staged[file] = true
+	}
+
+	status, err := workTree.Status()
 	if err != nil {
-		return fmt.Errorf("could not add files to repo %s: %v", path, err)
+		return fmt.Errorf("could not get status of repo %s: %v", path, err)
 	}
 
-	_, err = workTree.Commit(message, &git.CommitOptions{})
+	var unstaged, strays []string
+	var hasChanges bool
+
+	for file, fileStatus := range status {
+		if staged[file] {
+			hasChanges = hasChanges || fileStatus.Staging != git.Unmodified
+
+			continue
+		}
+
+		// the commit contains the whole index, so files staged by someone
+		// else have to be unstaged
+		if fileStatus.Staging != git.Unmodified && fileStatus.Staging != git.Untracked {
+			strays = append(strays, file)
+		} else if fileStatus.Worktree != git.Unmodified {
+			unstaged = append(unstaged, file)
+		}
+	}
+
+	err = unstageFiles(repo, head.Hash(), strays)
+	if err != nil {
+		return fmt.Errorf("could not unstage files of repo %s: %v", path, err)
+	}
+
+	for _, file := range sortedStrings(strays) {
+		sop.Warn(fmt.Sprintf("warning: %s in repo %s is staged but not tracked by dotf, it was unstaged and will not be committed\n", file, path))
+	}
+
+	for _, file := range sortedStrings(unstaged) {
+		sop.Warn(fmt.Sprintf("warning: %s in repo %s is not tracked by dotf and will not be committed\n", file, path))
+	}
+
+	if !hasChanges {
+		return dotf.ErrNothingToCommit
+	}
+
+	signKey, err := sop.signingKey(gitCfg)
 	if err != nil {
 		return fmt.Errorf("could not commit to repo %s: %v", path, err)
 	}
 
-	err = repo.Push(&git.PushOptions{})
+	commitAuthor, err := author(gitCfg)
+	if err != nil {
+		return fmt.Errorf("could not commit to repo %s: %v", path, err)
+	}
+
+	_, err = workTree.Commit(message, &git.CommitOptions{Author: commitAuthor, SignKey: signKey})
+	if err != nil {
+		return fmt.Errorf("could not commit to repo %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// PushRepo pushes the configured branch of a git repository to the configured
+// remote.
+func (sop *Provider) PushRepo(path string, gitCfg dotf.GitConfig) error {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return fmt.Errorf("could not open repo %s: %v", path, err)
+	}
+
+	branch, err := branchName(repo, gitCfg)
 	if err != nil {
 		return fmt.Errorf("could not push repo %s: %v", path, err)
 	}
 
+	auth, err := sop.authMethod(gitCfg)
+	if err != nil {
+		return fmt.Errorf("could not authenticate for repo %s: %v", path, err)
+	}
+
+	err = repo.Push(&git.PushOptions{
+		RemoteName: remoteName(gitCfg),
+		RefSpecs:   []config.RefSpec{branchRefSpec(branch)},
+		Auth:       auth,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return fmt.Errorf("could not push repo %s: %v", path, err)
+	}
+
 	return nil
 }
+
+// CreateTag creates an annotated tag for HEAD of a git repository.
+func (sop *Provider) CreateTag(path, name, message string, gitCfg dotf.GitConfig) error {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return fmt.Errorf("could not open repo %s: %v", path, err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("could not read HEAD of repo %s: %v", path, err)
+	}
+
+	signKey, err := sop.signingKey(gitCfg)
+	if err != nil {
+		return fmt.Errorf("could not create tag %s in repo %s: %v", name, path, err)
+	}
+
+	tagger, err := author(gitCfg)
+	if err != nil {
+		return fmt.Errorf("could not create tag %s in repo %s: %v", name, path, err)
+	}
+
+	_, err = repo.CreateTag(name, head.Hash(), &git.CreateTagOptions{
+		Tagger:  tagger,
+		Message: message,
+		SignKey: signKey,
+	})
+	if err == git.ErrTagExists {
+		return fmt.Errorf("tag %s already exists in repo %s", name, path)
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not create tag %s in repo %s: %v", name, path, err)
+	}
+
+	return nil
+}
+
+// PushTag pushes a tag of a git repository to the configured remote.
+func (sop *Provider) PushTag(path, name string, gitCfg dotf.GitConfig) error {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return fmt.Errorf("could not open repo %s: %v", path, err)
+	}
+
+	auth, err := sop.authMethod(gitCfg)
+	if err != nil {
+		return fmt.Errorf("could not authenticate for repo %s: %v", path, err)
+	}
+
+	err = repo.Push(&git.PushOptions{
+		RemoteName: remoteName(gitCfg),
+		RefSpecs:   []config.RefSpec{tagRefSpec(name)},
+		Auth:       auth,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return fmt.Errorf("could not push tag %s of repo %s: %v", name, path, err)
+	}
+
+	return nil
+}
+
+// ListTags returns all tags of a git repository whose name starts with
+// prefix, newest first.
+func (sop *Provider) ListTags(path, prefix string) ([]dotf.Tag, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open repo %s: %v", path, err)
+	}
+
+	refs, err := repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("could not list tags of repo %s: %v", path, err)
+	}
+
+	var tags []dotf.Tag
+
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().Short()
+		if !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+
+		tag, err := describeTag(repo, name, ref.Hash())
+		if err != nil {
+			return err
+		}
+
+		tags = append(tags, tag)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list tags of repo %s: %v", path, err)
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].When.After(tags[j].When)
+	})
+
+	return tags, nil
+}
+
+// ListUnpushedCommits returns the commits of the configured branch of a git
+// repository which are not on the configured remote, newest first.
+func (sop *Provider) ListUnpushedCommits(path string, gitCfg dotf.GitConfig) ([]dotf.Commit, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open repo %s: %v", path, err)
+	}
+
+	branch, err := branchName(repo, gitCfg)
+	if err != nil {
+		return nil, fmt.Errorf("could not inspect repo %s: %v", path, err)
+	}
+
+	commits, err := unpushedCommits(repo, remoteName(gitCfg), branch)
+	if err != nil {
+		return nil, fmt.Errorf("could not inspect repo %s: %v", path, err)
+	}
+
+	return commits, nil
+}
+
+// UnpushedCommits returns how many commits of the configured branch of a git
+// repository are not on the configured remote, as of the last fetch or push.
+func (sop *Provider) UnpushedCommits(path string, gitCfg dotf.GitConfig) (int, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return 0, fmt.Errorf("could not open repo %s: %v", path, err)
+	}
+
+	branch, err := branchName(repo, gitCfg)
+	if err != nil {
+		return 0, fmt.Errorf("could not inspect repo %s: %v", path, err)
+	}
+
+	count, err := countUnpushedCommits(repo, remoteName(gitCfg), branch)
+	if err != nil {
+		return 0, fmt.Errorf("could not inspect repo %s: %v", path, err)
+	}
+
+	return count, nil
+}
@@ -1,9 +1,25 @@
 package sysop_test
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"bakku.dev/dotf"
 	"bakku.dev/dotf/sysop"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
 func TestPathExists(t *testing.T) {
@@ -21,3 +37,1052 @@ func TestPathExists(t *testing.T) {
 		t.Fatal("expected ../sysop to exist")
 	}
 }
+
+func TestCopyFile_ShouldCreateMissingDirectories(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dotf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "config")
+	dest := filepath.Join(dir, ".config", "i3", "config")
+
+	writeFile(t, src, "bindsym $mod+Return exec i3-sensible-terminal")
+
+	op := sysop.Provider{}
+
+	err = op.CopyFile(src, dest)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	if !op.PathExists(dest) {
+		t.Fatalf("expected %s to exist", dest)
+	}
+}
+
+func TestDirtyFilesAndDiscardChanges(t *testing.T) {
+	path, _, cleanup := setupRepo(t)
+	defer cleanup()
+
+	op := sysop.Provider{}
+
+	writeFile(t, filepath.Join(path, "README"), "edited by hand")
+	writeFile(t, filepath.Join(path, ".vimrc"), "set number")
+	writeFile(t, filepath.Join(path, ".vimrc.swp"), "garbage")
+
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workTree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = workTree.Add(".vimrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := op.DirtyFiles(path)
+	if err != nil || !reflect.DeepEqual(files, []string{".vimrc", "README"}) {
+		t.Fatalf("expected .vimrc and README to be dirty, got %v, %v", files, err)
+	}
+
+	err = op.DiscardChanges(path, files)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	files, err = op.DirtyFiles(path)
+	if err != nil || len(files) != 0 {
+		t.Fatalf("expected no dirty files, got %v, %v", files, err)
+	}
+
+	if op.PathExists(filepath.Join(path, ".vimrc")) {
+		t.Fatal("expected staged .vimrc to be removed")
+	}
+
+	if !op.PathExists(filepath.Join(path, ".vimrc.swp")) {
+		t.Fatal("expected untracked .vimrc.swp to be kept")
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(path, "README"))
+	if err != nil || string(content) != "dotfiles" {
+		t.Fatalf("expected README to be restored, got %q, %v", content, err)
+	}
+}
+
+func TestDiscardChanges_ShouldRemoveUntrackedFiles(t *testing.T) {
+	path, _, cleanup := setupRepo(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(path, ".vimrc"), "set number")
+
+	op := sysop.Provider{}
+
+	err := op.DiscardChanges(path, []string{"/.vimrc"})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	if op.PathExists(filepath.Join(path, ".vimrc")) {
+		t.Fatalf("expected untracked file to be removed")
+	}
+}
+
+func TestCommitRepo_ShouldOnlyCommitGivenFiles(t *testing.T) {
+	path, remote, cleanup := setupRepo(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(path, ".vimrc"), "set number")
+	writeFile(t, filepath.Join(path, ".vimrc.swp"), "garbage")
+
+	op := sysop.Provider{}
+
+	err := op.CommitRepo(path, "Update .vimrc", []string{"/.vimrc", "/.bashrc"}, dotf.GitConfig{})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	err = op.PushRepo(path, dotf.GitConfig{})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	tree := headTree(t, remote)
+
+	if _, err := tree.File(".vimrc"); err != nil {
+		t.Fatalf("expected .vimrc to be committed: %v", err)
+	}
+
+	if _, err := tree.File(".vimrc.swp"); err == nil {
+		t.Fatal("expected .vimrc.swp not to be committed")
+	}
+}
+
+func TestCommitRepo_ShouldNotCommitFilesStagedBySomeoneElse(t *testing.T) {
+	path, _, cleanup := setupRepo(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(path, ".vimrc"), "set number")
+	writeFile(t, filepath.Join(path, "stray"), "password=hunter2")
+	writeFile(t, filepath.Join(path, "README"), "my dotfiles")
+
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workTree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{"stray", "README"} {
+		_, err = workTree.Add(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	op := sysop.Provider{}
+
+	err = op.CommitRepo(path, "Update .vimrc", []string{"/.vimrc"}, dotf.GitConfig{})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	tree := headTree(t, path)
+
+	if _, err := tree.File(".vimrc"); err != nil {
+		t.Fatalf("expected .vimrc to be committed: %v", err)
+	}
+
+	if _, err := tree.File("stray"); err == nil {
+		t.Fatal("expected stray not to be committed")
+	}
+
+	readme, err := tree.File("README")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if content, _ := readme.Contents(); content != "dotfiles" {
+		t.Fatalf("expected README change not to be committed, got %q", content)
+	}
+
+	status, err := workTree.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.File("stray").Staging != git.Untracked || status.File("README").Worktree != git.Modified {
+		t.Fatalf("expected stray and README to be unstaged but kept, got %v", status)
+	}
+}
+
+func TestCommitRepo_ShouldReturnErrNothingToCommitWithoutChanges(t *testing.T) {
+	path, remote, cleanup := setupRepo(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(path, ".vimrc.swp"), "garbage")
+
+	op := sysop.Provider{}
+
+	err := op.CommitRepo(path, "Update README", []string{"/README"}, dotf.GitConfig{})
+	if err != dotf.ErrNothingToCommit {
+		t.Fatalf("expected ErrNothingToCommit, got %v", err)
+	}
+
+	if headHash(t, path) != headHash(t, remote) {
+		t.Fatal("expected no commit to be created")
+	}
+}
+
+func TestCommitRepo_ShouldUseConfiguredAuthor(t *testing.T) {
+	path, remote, cleanup := setupRepo(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(path, ".vimrc"), "set number")
+
+	op := sysop.Provider{}
+	gitCfg := dotf.GitConfig{Branch: "master", AuthorName: "Jane Doe", AuthorEmail: "jane@example.com"}
+
+	err := op.CommitRepo(path, "Update .vimrc", []string{"/.vimrc"}, gitCfg)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	err = op.PushRepo(path, gitCfg)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	commit := headCommit(t, remote)
+
+	if commit.Author.Name != "Jane Doe" || commit.Author.Email != "jane@example.com" {
+		t.Fatalf("expected author Jane Doe <jane@example.com>, got %v", commit.Author)
+	}
+}
+
+func TestCommitRepo_ShouldFailIfAuthorIsIncomplete(t *testing.T) {
+	path, remote, cleanup := setupRepo(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(path, ".vimrc"), "set number")
+
+	op := sysop.Provider{}
+	gitCfg := dotf.GitConfig{Branch: "master", AuthorName: "Jane Doe"}
+
+	err := op.CommitRepo(path, "Update .vimrc", []string{"/.vimrc"}, gitCfg)
+	if err == nil || !strings.Contains(err.Error(), "authorEmail") {
+		t.Fatalf("expected err to name the missing authorEmail, got %v", err)
+	}
+
+	if headHash(t, path) != headHash(t, remote) {
+		t.Fatal("expected no commit to be created")
+	}
+}
+
+func TestCommitRepo_ShouldFailIfRepoIsOnAnotherBranch(t *testing.T) {
+	path, _, cleanup := setupRepo(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(path, ".vimrc"), "set number")
+
+	op := sysop.Provider{}
+
+	err := op.CommitRepo(path, "Update .vimrc", []string{"/.vimrc"}, dotf.GitConfig{Branch: "laptop"})
+	if err == nil {
+		t.Fatal("expected err not to be nil")
+	}
+}
+
+func TestUpdateRepo_ShouldCheckoutConfiguredBranch(t *testing.T) {
+	path, remote, cleanup := setupRepo(t)
+	defer cleanup()
+
+	other := cloneRepo(t, remote, filepath.Join(filepath.Dir(path), "other"))
+	checkoutNewBranch(t, other, "laptop")
+	writeFile(t, filepath.Join(other, ".vimrc"), "set number")
+
+	op := sysop.Provider{}
+	gitCfg := dotf.GitConfig{Remote: "origin", Branch: "laptop"}
+
+	err := op.CommitRepo(other, "Update .vimrc", []string{"/.vimrc"}, gitCfg)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	err = op.PushRepo(other, gitCfg)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	err = op.UpdateRepo(path, gitCfg)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	if !op.PathExists(filepath.Join(path, ".vimrc")) {
+		t.Fatal("expected .vimrc to be pulled")
+	}
+
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := repo.Head()
+	if err != nil || head.Name().Short() != "laptop" {
+		t.Fatalf("expected branch laptop to be checked out, got %v", head)
+	}
+}
+
+func TestUpdateRepo_ShouldFailIfBranchDoesNotExistOnRemote(t *testing.T) {
+	path, _, cleanup := setupRepo(t)
+	defer cleanup()
+
+	op := sysop.Provider{}
+
+	err := op.UpdateRepo(path, dotf.GitConfig{Branch: "laptop"})
+	if err == nil || !strings.Contains(err.Error(), "branch laptop does not exist on remote origin") {
+		t.Fatalf("expected missing branch error, got %v", err)
+	}
+}
+
+func TestUpdateRepoAndCommitRepo_ShouldSendConfiguredCredentials(t *testing.T) {
+	path, _, cleanup := setupRepo(t)
+	defer cleanup()
+
+	var users []string
+	var mutex sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _, _ := r.BasicAuth()
+		mutex.Lock()
+		users = append(users, user)
+		mutex.Unlock()
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	addRemote(t, path, "http", server.URL+"/dotfiles.git")
+
+	err := os.Setenv("DOTF_GIT_PASSWORD", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("DOTF_GIT_PASSWORD")
+
+	gitCfg := dotf.GitConfig{Remote: "http", Auth: dotf.GitAuth{Method: "http-basic", User: "jane"}}
+	op := sysop.Provider{}
+
+	if err := op.UpdateRepo(path, gitCfg); err == nil {
+		t.Fatal("expected err not to be nil")
+	}
+
+	writeFile(t, filepath.Join(path, ".vimrc"), "set number")
+
+	if err := op.CommitRepo(path, "Update .vimrc", []string{"/.vimrc"}, gitCfg); err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	if err := op.PushRepo(path, gitCfg); err == nil {
+		t.Fatal("expected err not to be nil")
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if len(users) != 2 || users[0] != "jane" || users[1] != "jane" {
+		t.Fatalf("expected fetch and push to authenticate as jane, got %v", users)
+	}
+}
+
+func TestUnpushedCommits_ShouldCountCommitsUntilPushed(t *testing.T) {
+	path, _, cleanup := setupRepo(t)
+	defer cleanup()
+
+	op := sysop.Provider{}
+
+	for _, content := range []string{"set number", "set nonumber"} {
+		writeFile(t, filepath.Join(path, ".vimrc"), content)
+
+		err := op.CommitRepo(path, "Update .vimrc", []string{"/.vimrc"}, dotf.GitConfig{})
+		if err != nil {
+			t.Fatalf("expected err to be nil, got %v", err)
+		}
+	}
+
+	count, err := op.UnpushedCommits(path, dotf.GitConfig{})
+	if err != nil || count != 2 {
+		t.Fatalf("expected 2 unpushed commits, got %d, %v", count, err)
+	}
+
+	commits, err := op.ListUnpushedCommits(path, dotf.GitConfig{})
+	if err != nil || len(commits) != 2 || commits[0].Hash != headHash(t, path) || commits[0].Message != "Update .vimrc" {
+		t.Fatalf("expected the 2 unpushed commits newest first, got %v, %v", commits, err)
+	}
+
+	err = op.PushRepo(path, dotf.GitConfig{})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	count, err = op.UnpushedCommits(path, dotf.GitConfig{})
+	if err != nil || count != 0 {
+		t.Fatalf("expected no unpushed commits, got %d, %v", count, err)
+	}
+
+	commits, err = op.ListUnpushedCommits(path, dotf.GitConfig{})
+	if err != nil || len(commits) != 0 {
+		t.Fatalf("expected no unpushed commits, got %v, %v", commits, err)
+	}
+}
+
+func TestRepoChanges_ShouldListChangedFiles(t *testing.T) {
+	path, _, cleanup := setupRepo(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(path, ".vimrc"), "set number")
+	writeFile(t, filepath.Join(path, "README"), "my dotfiles")
+	writeFile(t, filepath.Join(path, ".vimrc.swp"), "garbage")
+
+	op := sysop.Provider{}
+
+	changes, err := op.RepoChanges(path, []string{"/.vimrc", "/README", "/.bashrc"})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	expected := dotf.FileChanges{Added: []string{".vimrc"}, Modified: []string{"README"}}
+
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("expected %v, got %v", expected, changes)
+	}
+}
+
+func TestOpenRepoReader_ShouldReadTaggedContentWithoutCheckout(t *testing.T) {
+	path, _, cleanup := setupRepo(t)
+	defer cleanup()
+
+	op := sysop.Provider{}
+
+	writeFile(t, filepath.Join(path, ".vimrc"), "set number")
+
+	err := op.CommitRepo(path, "Add .vimrc", []string{"/.vimrc"}, dotf.GitConfig{})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	tagged := headHash(t, path)
+
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = repo.CreateTag("v1", plumbing.NewHash(tagged), &git.CreateTagOptions{Message: "v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(path, ".vimrc"), "set nonumber")
+
+	err = op.CommitRepo(path, "Update .vimrc", []string{"/.vimrc"}, dotf.GitConfig{})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	revision, err := op.ResolveRevision(path, "v1")
+	if err != nil || revision != tagged {
+		t.Fatalf("expected v1 to resolve to %s, got %s, %v", tagged, revision, err)
+	}
+
+	tagReader, err := op.OpenRepoReader(path, "v1")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	content, err := tagReader.ReadFile("/.vimrc")
+	if err != nil || string(content) != "set number" {
+		t.Fatalf("expected tagged content, got %q, %v", content, err)
+	}
+
+	_, err = tagReader.ReadFile("/.bashrc")
+	if err != dotf.ErrNotInRepo {
+		t.Fatalf("expected ErrNotInRepo, got %v", err)
+	}
+
+	worktreeReader, err := op.OpenRepoReader(path, "")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	content, err = worktreeReader.ReadFile("/.vimrc")
+	if err != nil || string(content) != "set nonumber" {
+		t.Fatalf("expected worktree content, got %q, %v", content, err)
+	}
+
+	_, err = worktreeReader.ReadFile("/.bashrc")
+	if err != dotf.ErrNotInRepo {
+		t.Fatalf("expected ErrNotInRepo, got %v", err)
+	}
+}
+
+func TestFileHistory_ShouldListCommitsWhichChangedFile(t *testing.T) {
+	path, _, cleanup := setupRepo(t)
+	defer cleanup()
+
+	op := sysop.Provider{}
+
+	commits := []struct{ file, content, message string }{
+		{".vimrc", "set number", "Add .vimrc"},
+		{".bashrc", "PS1=$", "Add .bashrc"},
+		{".vimrc", "set nonumber", "Disable line numbers"},
+	}
+
+	for _, c := range commits {
+		writeFile(t, filepath.Join(path, c.file), c.content)
+
+		err := op.CommitRepo(path, c.message, []string{c.file}, dotf.GitConfig{})
+		if err != nil {
+			t.Fatalf("expected err to be nil, got %v", err)
+		}
+	}
+
+	history, err := op.FileHistory(path, "/.vimrc")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	if len(history) != 2 || history[0].Message != "Disable line numbers" || history[1].Message != "Add .vimrc" {
+		t.Fatalf("expected the two .vimrc commits newest first, got %v", history)
+	}
+
+	if history[0].Hash != headHash(t, path) || history[0].Author != "dotf" {
+		t.Fatalf("expected newest commit to be HEAD by dotf, got %v", history[0])
+	}
+}
+
+func TestCreateTagAndListTags_ShouldTagHeadAndPushTag(t *testing.T) {
+	path, remote, cleanup := setupRepo(t)
+	defer cleanup()
+
+	op := sysop.Provider{}
+
+	err := op.CreateTag(path, "snapshot/clean", "Snapshot clean", dotf.GitConfig{})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	err = op.CreateTag(path, "snapshot/clean", "Snapshot clean", dotf.GitConfig{})
+	if err == nil {
+		t.Fatalf("expected creating an existing tag to fail")
+	}
+
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = repo.CreateTag("v1", plumbing.NewHash(headHash(t, path)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := op.ListTags(path, "snapshot/")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	if len(tags) != 1 || tags[0].Name != "snapshot/clean" || tags[0].Commit != headHash(t, path) ||
+		tags[0].Message != "Snapshot clean\n" {
+		t.Fatalf("expected only the snapshot tag pointing to HEAD, got %v", tags)
+	}
+
+	err = op.PushTag(path, "snapshot/clean", dotf.GitConfig{})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	remoteRepo, err := git.PlainOpen(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = remoteRepo.Tag("snapshot/clean")
+	if err != nil {
+		t.Fatalf("expected tag to be pushed, got %v", err)
+	}
+}
+
+// setupRepo creates a repository with an initial commit which is cloned from
+// a local bare repository acting as the remote.
+func setupRepo(t *testing.T) (string, string, func()) {
+	dir, err := ioutil.TempDir("", "dotf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "repo")
+	remote := filepath.Join(dir, "remote.git")
+
+	_, err = git.PlainInit(remote, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := git.PlainInit(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remote}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setUser(t, repo)
+
+	writeFile(t, filepath.Join(path, "README"), "dotfiles")
+
+	workTree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = workTree.Add("README")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = workTree.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "dotf", Email: "dotf@localhost", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = repo.Push(&git.PushOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path, remote, func() { os.RemoveAll(dir) }
+}
+
+// cloneRepo clones remote into path and configures a committer.
+func cloneRepo(t *testing.T, remote, path string) string {
+	repo, err := git.PlainClone(path, false, &git.CloneOptions{URL: remote})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setUser(t, repo)
+
+	return path
+}
+
+func addRemote(t *testing.T, path, name, url string) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: name, URLs: []string{url}})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkoutNewBranch(t *testing.T, path, branch string) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workTree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = workTree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(branch), Create: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func setUser(t *testing.T, repo *git.Repository) {
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.User.Name = "dotf"
+	cfg.User.Email = "dotf@localhost"
+
+	err = repo.SetConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func headHash(t *testing.T, path string) string {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return head.Hash().String()
+}
+
+func headCommit(t *testing.T, path string) *object.Commit {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return commit
+}
+
+func headTree(t *testing.T, path string) *object.Tree {
+	tree, err := headCommit(t, path).Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tree
+}
+
+func writeFile(t *testing.T, path, content string) {
+	err := ioutil.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunCommand_ShouldPassStdinAndReturnExitCode(t *testing.T) {
+	op := sysop.Provider{}
+
+	output, exitCode, err := op.RunCommand(dotf.Command{Line: "read line; echo \"got $line\"; exit 3", Stdin: []byte("set number\n")})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+
+	if exitCode != 3 {
+		t.Fatalf("expected exit code 3, got %d", exitCode)
+	}
+
+	if string(output) != "got set number\n" {
+		t.Fatalf("expected output to contain stdin, got %q", output)
+	}
+}
+
+func TestRunCommand_ShouldRunInDirWithAdditionalEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dotf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	op := sysop.Provider{}
+
+	output, exitCode, err := op.RunCommand(dotf.Command{
+		Line: "echo \"$DOTF_OPERATION $HOME\"",
+		Dir:  dir,
+		Env:  []string{"DOTF_OPERATION=pull"},
+	})
+	if err != nil || exitCode != 0 {
+		t.Fatalf("expected command to succeed, got exit code %d and err %v", exitCode, err)
+	}
+
+	writeFile(t, filepath.Join(dir, "marker"), "")
+
+	expected := "pull " + os.Getenv("HOME") + "\n"
+	if string(output) != expected {
+		t.Fatalf("expected output %q, got %q", expected, output)
+	}
+
+	output, _, err = op.RunCommand(dotf.Command{Line: "ls", Dir: dir})
+	if err != nil || string(output) != "marker\n" {
+		t.Fatalf("expected command to run in %s, got %q and err %v", dir, output, err)
+	}
+}
+
+func TestWatchFiles_ShouldReportChangesOfWatchedFilesOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dotf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	vimrc := filepath.Join(dir, ".vimrc")
+	writeFile(t, vimrc, "set number")
+
+	op := sysop.Provider{}
+
+	watcher, err := op.WatchFiles([]string{vimrc})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got %v", err)
+	}
+	defer watcher.Close()
+
+	writeFile(t, filepath.Join(dir, ".viminfo"), "history")
+	writeFile(t, vimrc+".tmp", "set nonumber")
+
+	err = os.Rename(vimrc+".tmp", vimrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case path := <-watcher.Events():
+		if path != vimrc {
+			t.Fatalf("expected a change of %s, got %s", vimrc, path)
+		}
+	case err := <-watcher.Errors():
+		t.Fatalf("expected no error, got %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the replaced file to be reported")
+	}
+}
+
+func TestCreateLockFile_ShouldNotReplaceExistingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dotf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, ".dotf.lock")
+	op := sysop.Provider{}
+
+	created, err := op.CreateLockFile(path, []byte("first"))
+	if err != nil || !created {
+		t.Fatalf("expected lock file to be created, got %v and err %v", created, err)
+	}
+
+	created, err = op.CreateLockFile(path, []byte("second"))
+	if err != nil || created {
+		t.Fatalf("expected existing lock file to be kept, got %v and err %v", created, err)
+	}
+
+	content, err := op.ReadFile(path)
+	if err != nil || string(content) != "first" {
+		t.Fatalf("expected content of first lock, got %q and err %v", content, err)
+	}
+
+	if !op.ProcessExists(op.GetPid()) {
+		t.Fatal("expected the test process to exist")
+	}
+}
+
+func TestRemoveLockFile_ShouldOnlyRemoveUnchangedLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dotf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, ".dotf.lock")
+	op := sysop.Provider{}
+
+	_, err = op.CreateLockFile(path, []byte("stale"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// another dotf removes the stale lock and takes it again
+	err = op.RemoveFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = op.CreateLockFile(path, []byte("taken"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := op.RemoveLockFile(path, []byte("stale"))
+	if err != nil || removed {
+		t.Fatalf("expected the taken lock to be kept, got %v and err %v", removed, err)
+	}
+
+	content, err := op.ReadFile(path)
+	if err != nil || string(content) != "taken" {
+		t.Fatalf("expected content of the taken lock, got %q and err %v", content, err)
+	}
+
+	removed, err = op.RemoveLockFile(path, []byte("taken"))
+	if err != nil || !removed {
+		t.Fatalf("expected the lock to be removed, got %v and err %v", removed, err)
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expected no files to be left, got %d entries and err %v", len(entries), err)
+	}
+}
+
+func TestReplaceFile_ShouldOnlyReplaceUnchangedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dotf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, ".dotf")
+	op := sysop.Provider{}
+
+	err = op.WriteFile(path, []byte("first"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = op.ReplaceFile(path, []byte("first"), []byte("second"))
+	if err != nil {
+		t.Fatalf("expected file to be replaced, got err %v", err)
+	}
+
+	err = op.ReplaceFile(path, []byte("first"), []byte("third"))
+	if !errors.Is(err, dotf.ErrConflict) {
+		t.Fatalf("expected conflict, got err %v", err)
+	}
+
+	content, err := op.ReadFile(path)
+	if err != nil || string(content) != "second" {
+		t.Fatalf("expected content of second write, got %q and err %v", content, err)
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected no temporary files to be left, got %d entries and err %v", len(entries), err)
+	}
+}
+
+func TestReplaceFile_ShouldLetOnlyOneConcurrentReplacementWin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dotf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, ".dotf")
+	op := sysop.Provider{}
+
+	err = op.WriteFile(path, []byte("first"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, 20)
+
+	for i := 0; i < cap(errs); i++ {
+		go func(i int) {
+			errs <- op.ReplaceFile(path, []byte("first"), []byte(fmt.Sprintf("replacement %d", i)))
+		}(i)
+	}
+
+	replaced := 0
+
+	for i := 0; i < cap(errs); i++ {
+		err := <-errs
+		if err == nil {
+			replaced++
+		} else if !errors.Is(err, dotf.ErrConflict) {
+			t.Fatalf("expected conflict, got err %v", err)
+		}
+	}
+
+	if replaced != 1 {
+		t.Fatalf("expected exactly one replacement, got %d", replaced)
+	}
+}
+
+func TestReplaceFile_ShouldReplaceTargetOfSymlink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dotf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "dotfiles.json")
+	path := filepath.Join(dir, ".dotf")
+	op := sysop.Provider{}
+
+	err = op.WriteFile(target, []byte("first"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Symlink(target, path)
+	if err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+
+	err = op.ReplaceFile(path, []byte("first"), []byte("second"))
+	if err != nil {
+		t.Fatalf("expected file to be replaced, got err %v", err)
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected %s to stay a symlink, got err %v", path, err)
+	}
+
+	content, err := op.ReadFile(target)
+	if err != nil || string(content) != "second" {
+		t.Fatalf("expected replaced content in the target, got %q and err %v", content, err)
+	}
+}
+
+func TestReadLine_ShouldNotDropBufferedLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dotf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "answers")
+	writeFile(t, path, "d\ny\nsecret\n")
+
+	stdin, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+
+	previous := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = previous }()
+
+	op := sysop.Provider{}
+
+	for _, expected := range []string{"d", "y", "secret"} {
+		line, err := op.ReadLine()
+		if err != nil || line != expected {
+			t.Fatalf("expected line %q, got %q and err %v", expected, line, err)
+		}
+	}
+}
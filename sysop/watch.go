@@ -0,0 +1,129 @@
+package sysop
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"bakku.dev/dotf"
+	"github.com/fsnotify/fsnotify"
+)
+
+// ShutdownRequested returns a channel which is closed once dotf receives
+// SIGINT or SIGTERM.
+func (sop *Provider) ShutdownRequested() <-chan struct{} {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+
+	go func() {
+		<-signals
+		signal.Stop(signals)
+		close(done)
+	}()
+
+	return done
+}
+
+// WatchFiles watches the given files for changes. The parent directories are
+// watched instead of the files themselves, so files which editors replace
+// instead of writing them in place are still reported.
+func (sop *Provider) WatchFiles(paths []string) (dotf.FileWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("could not create file watcher: %v", err)
+	}
+
+	fw := &fileWatcher{
+		watcher: watcher,
+		files:   make(map[string]bool),
+		events:  make(chan string),
+		errors:  make(chan error),
+		done:    make(chan struct{}),
+	}
+
+	dirs := make(map[string]bool)
+
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		fw.files[path] = true
+
+		dir := filepath.Dir(path)
+		if dirs[dir] {
+			continue
+		}
+
+		err = watcher.Add(dir)
+		if err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("could not watch %s: %v", dir, err)
+		}
+
+		dirs[dir] = true
+	}
+
+	go fw.forward()
+
+	return fw, nil
+}
+
+// fileWatcher filters the events of the watched directories down to the
+// watched files.
+type fileWatcher struct {
+	watcher *fsnotify.Watcher
+	files   map[string]bool
+	events  chan string
+	errors  chan error
+	done    chan struct{}
+}
+
+func (fw *fileWatcher) Events() <-chan string {
+	return fw.events
+}
+
+func (fw *fileWatcher) Errors() <-chan error {
+	return fw.errors
+}
+
+func (fw *fileWatcher) Close() error {
+	close(fw.done)
+
+	return fw.watcher.Close()
+}
+
+func (fw *fileWatcher) forward() {
+	for {
+		select {
+		case event, ok := <-fw.watcher.Events:
+			if !ok {
+				return
+			}
+
+			path := filepath.Clean(event.Name)
+			if !fw.files[path] || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+
+			select {
+			case fw.events <- path:
+			case <-fw.done:
+				return
+			}
+		case err, ok := <-fw.watcher.Errors:
+			if !ok {
+				return
+			}
+
+			select {
+			case fw.errors <- err:
+			case <-fw.done:
+				return
+			}
+		case <-fw.done:
+			return
+		}
+	}
+}